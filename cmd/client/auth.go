@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+var (
+	tokenFile  = flag.String("token-file", "", "file holding the bearer token the gateway calls the grpc servers with, read on every call")
+	apiKeyFile = flag.String("api-key-file", "", "file holding the api key the gateway calls the grpc servers with, read on every call")
+
+	insecureCredentials = flag.Bool("insecure-credentials", false, "allow the token-file or api-key-file credentials over plaintext, for local testing only")
+)
+
+// requestCredentials returns the Authorization and X-Api-Key headers of a
+// request as metadata key value pairs
+func requestCredentials(c *gin.Context) []string {
+	var kv []string
+	if h := c.GetHeader("Authorization"); h != "" {
+		kv = append(kv, auth.AUTHORIZATION_HEADER, h)
+	}
+	if h := c.GetHeader("X-Api-Key"); h != "" {
+		kv = append(kv, auth.API_KEY_HEADER, h)
+	}
+	return kv
+}
+
+// callerOf identifies the caller of a request by a hash of its credentials,
+// empty for callers without any. The gateway can't verify credentials, so
+// the same ones must be presented again to be the same caller.
+func callerOf(c *gin.Context) string {
+	kv := requestCredentials(c)
+	if len(kv) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(kv, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// forwardCredentials passes the Authorization or X-Api-Key header of a
+// request on to the grpc calls made for it, so the servers authenticate the
+// caller instead of the gateway
+func forwardCredentials(c *gin.Context) {
+	if kv := requestCredentials(c); len(kv) > 0 {
+		ctx := metadata.AppendToOutgoingContext(c.Request.Context(), kv...)
+		c.Request = c.Request.WithContext(ctx)
+	}
+	c.Next()
+}
+
+// gatewayCredentials returns the credentials of the token or api key file,
+// nil when neither is set
+func gatewayCredentials() (credentials.PerRPCCredentials, error) {
+	switch {
+	case *tokenFile != "" && *apiKeyFile != "":
+		return nil, errors.New("token-file and api-key-file are exclusive")
+	case *tokenFile != "":
+		return fileCredentials{path: *tokenFile, header: auth.AUTHORIZATION_HEADER, prefix: "Bearer ", insecure: *insecureCredentials}, nil
+	case *apiKeyFile != "":
+		return fileCredentials{path: *apiKeyFile, header: auth.API_KEY_HEADER, insecure: *insecureCredentials}, nil
+	}
+	return nil, nil
+}
+
+// fileCredentials sends the content of a file with every call which doesn't
+// carry forwarded credentials. The file is read on every call, so it can be
+// rotated in place.
+type fileCredentials struct {
+	path   string
+	header string
+	prefix string
+	// insecure allows sending the credentials over plaintext
+	insecure bool
+}
+
+func (f fileCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if len(md.Get(auth.AUTHORIZATION_HEADER)) > 0 || len(md.Get(auth.API_KEY_HEADER)) > 0 {
+			return nil, nil
+		}
+	}
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials: %v", err)
+	}
+	secret := strings.TrimSpace(string(b))
+	if secret == "" {
+		return nil, fmt.Errorf("no credentials in %s", f.path)
+	}
+	return map[string]string{f.header: f.prefix + secret}, nil
+}
+
+// RequireTransportSecurity keeps the credentials off plaintext connections
+// unless -insecure-credentials is set
+func (f fileCredentials) RequireTransportSecurity() bool {
+	return !f.insecure
+}
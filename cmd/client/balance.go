@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // client side health checking
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+)
+
+var (
+	serviceConfig = flag.String("service-config", "", "json grpc service config file replacing the default balancing, health check and retry policy")
+)
+
+// defaultServiceConfig balances calls over every server address, skipping
+// servers whose health service reports xref.XrefService is not serving.
+// Idempotent reads are retried on another server when one is unavailable.
+const defaultServiceConfig = `{
+	"loadBalancingConfig": [{"round_robin": {}}],
+	"healthCheckConfig": {"serviceName": "xref.XrefService"},
+	"methodConfig": [{
+		"name": [
+			{"service": "xref.XrefService", "method": "LookupXref"},
+			{"service": "xref.XrefService", "method": "GetMagicNumbers"},
+			{"service": "xref.XrefService", "method": "GetMagicNumberSummary"},
+			{"service": "xref.XrefService", "method": "GetPoolStats"}
+		],
+		"retryPolicy": {
+			"maxAttempts": 4,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}
+	}]
+}`
+
+// SERVERS_SCHEME is the resolver scheme of a list of server addresses
+const SERVERS_SCHEME string = "xref"
+
+// dialTarget returns the target and dial options balancing over addrs,
+// either a comma separated list of server addresses or a single target.
+// Targets without a scheme are resolved by dns, so every address of a name
+// is balanced over.
+func dialTarget(addrs string) (string, []grpc.DialOption, error) {
+
+	config := defaultServiceConfig
+	if *serviceConfig != "" {
+		b, err := os.ReadFile(*serviceConfig)
+		if err != nil {
+			return "", nil, err
+		}
+		if !json.Valid(b) {
+			return "", nil, fmt.Errorf("service config %s is not valid json", *serviceConfig)
+		}
+		config = string(b)
+	}
+	opts := []grpc.DialOption{grpc.WithDefaultServiceConfig(config)}
+
+	if !strings.Contains(addrs, ",") {
+		if !strings.Contains(addrs, "://") {
+			addrs = "dns:///" + addrs
+		}
+		return addrs, opts, nil
+	}
+
+	var state resolver.State
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			// each server is verified against its own name over TLS
+			state.Addresses = append(state.Addresses, resolver.Address{Addr: addr, ServerName: addr})
+		}
+	}
+	r := manual.NewBuilderWithScheme(SERVERS_SCHEME)
+	r.InitialState(state)
+	return r.Scheme() + ":///servers", append(opts, grpc.WithResolvers(r)), nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	unaryTimeout  = flag.Duration("timeout", 10*time.Second, "deadline of simple rpc routes, 0 disables")
+	streamTimeout = flag.Duration("stream-timeout", time.Minute, "deadline of streaming rpc routes, 0 disables")
+	routeTimeout  = routeTimeouts{}
+)
+
+func init() {
+	flag.Var(routeTimeout, "route-timeout", "`name=duration` deadline of a single route, repeatable")
+}
+
+// routeTimeouts overrides the deadline of routes by name
+type routeTimeouts map[string]time.Duration
+
+func (t routeTimeouts) String() string {
+	var s []string
+	for name, d := range t {
+		s = append(s, name+"="+d.String())
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (t routeTimeouts) Set(v string) error {
+	name, d, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("expected name=duration, got %q", v)
+	}
+	timeout, err := time.ParseDuration(d)
+	if err != nil {
+		return err
+	}
+	t[name] = timeout
+	return nil
+}
+
+// deadlineRoutes are the names routes were registered with
+var deadlineRoutes = map[string]bool{}
+
+// deadline bounds the request context of the route name by its
+// route-timeout, or def when it has none. Handlers derive their gRPC calls
+// from the request context, so the calls are cancelled when the deadline
+// passes or the client disconnects.
+func deadline(name string, def time.Duration) gin.HandlerFunc {
+	deadlineRoutes[name] = true
+
+	d := def
+	if t, ok := routeTimeout[name]; ok {
+		d = t
+	}
+	if d <= 0 {
+		return func(c *gin.Context) {}
+	}
+
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// checkRouteTimeouts reports route-timeout flags naming no route
+func checkRouteTimeouts() error {
+	for name := range routeTimeout {
+		if !deadlineRoutes[name] {
+			var names []string
+			for n := range deadlineRoutes {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown route %q in -route-timeout, routes are %s", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HEALTH_SERVICE is the service whose health the servers report
+const HEALTH_SERVICE string = "xref.XrefService"
+
+// healthCheckTimeout bounds the readiness probe's health check
+const healthCheckTimeout = time.Second
+
+// readiness is the body of the readiness probe
+type readiness struct {
+	Ready bool `json:"ready"`
+	// Connectivity is the state of the grpc connection
+	Connectivity string `json:"connectivity"`
+	// Serving is the health status the server reports, UNIMPLEMENTED for
+	// servers without a health service
+	Serving string `json:"serving"`
+	Error   string `json:"error,omitempty"`
+}
+
+// healthz is the liveness probe, the gateway is alive while it serves
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// readyz is the readiness probe. The gateway is ready when its connection
+// to the servers is up and they report xref.XrefService as serving.
+func readyz(c *gin.Context) {
+	conn := c.MustGet("grpcsvr").(*grpc.ClientConn)
+
+	if conn.GetState() == connectivity.Idle {
+		conn.Connect()
+	}
+
+	/*******
+	* gRPC *
+	********/
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+	defer cancel()
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: HEALTH_SERVICE})
+
+	state := conn.GetState()
+	r := readiness{Connectivity: state.String()}
+	switch {
+	case status.Code(err) == codes.Unimplemented:
+		// readiness rests on the connection alone
+		r.Serving = code.Code_UNIMPLEMENTED.String()
+		r.Ready = state == connectivity.Ready
+	case status.Code(err) == codes.NotFound:
+		r.Serving = healthpb.HealthCheckResponse_SERVICE_UNKNOWN.String()
+	case err != nil:
+		r.Serving = healthpb.HealthCheckResponse_UNKNOWN.String()
+		r.Error = status.Convert(err).Message()
+	default:
+		r.Serving = res.GetStatus().String()
+		r.Ready = state == connectivity.Ready && res.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	}
+
+	if !r.Ready {
+		c.JSON(http.StatusServiceUnavailable, r)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
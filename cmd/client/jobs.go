@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	JOB_RUNNING   string = "running"
+	JOB_SUCCEEDED string = "succeeded"
+	JOB_FAILED    string = "failed"
+	JOB_CANCELED  string = "canceled"
+)
+
+// jobRetention is how long finished jobs can still be polled
+const jobRetention = time.Hour
+
+// job is a batch of keys allocated in the background
+type job struct {
+	mu sync.Mutex
+
+	ID         string     `json:"id"`
+	State      string     `json:"state"`
+	Total      int        `json:"total"`
+	Processed  int        `json:"processed"`
+	New        int        `json:"new"`
+	Existing   int        `json:"existing"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+
+	summary *xref.XrefSummary
+	cancel  context.CancelFunc
+	// owner is the caller which created the job, see callerOf
+	owner string
+}
+
+// snapshot returns a copy of the job's progress safe to render
+func (j *job) snapshot() *job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &job{
+		ID:         j.ID,
+		State:      j.State,
+		Total:      j.Total,
+		Processed:  j.Processed,
+		New:        j.New,
+		Existing:   j.Existing,
+		Failed:     j.Failed,
+		Error:      j.Error,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
+
+// jobStore keeps the gateway's batch jobs in memory
+type jobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+var jobs = &jobStore{jobs: make(map[string]*job)}
+
+func (s *jobStore) add(j *job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// drop jobs finished long ago
+	for id, old := range s.jobs {
+		old.mu.Lock()
+		expired := old.FinishedAt != nil && time.Since(*old.FinishedAt) > jobRetention
+		old.mu.Unlock()
+		if expired {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[j.ID] = j
+}
+
+// get returns the job id when owned by owner, other callers' jobs are
+// reported as not found
+func (s *jobStore) get(id, owner string) (*job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || subtle.ConstantTimeCompare([]byte(j.owner), []byte(owner)) != 1 {
+		return nil, false
+	}
+	return j, true
+}
+
+// run allocates the job's keys over a GetXrefs stream, counting each
+// response as it arrives
+func (j *job) run(ctx context.Context, xsvc xref.XrefServiceClient, keys []string) {
+
+	start := time.Now()
+	err := func() error {
+		stream, err := xsvc.GetXrefs(ctx)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			for _, key := range keys {
+				if err := stream.Send(&xref.XrefRequest{Lastfour: key}); err != nil {
+					return // the server's error is returned by Recv
+				}
+			}
+			stream.CloseSend()
+		}()
+
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			j.mu.Lock()
+			j.Processed++
+			switch {
+			case res.GetError() != nil:
+				j.Failed++
+			case res.GetCreated():
+				j.New++
+			default:
+				j.Existing++
+			}
+			j.mu.Unlock()
+		}
+	}()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	j.FinishedAt = &now
+	j.summary = &xref.XrefSummary{
+		TotalNew:     uint32(j.New),
+		TotalUpdated: uint32(j.Existing),
+		TotalFailed:  uint32(j.Failed),
+		ElapsedTime:  uint32(now.Sub(start).Milliseconds()),
+	}
+	switch {
+	case err == nil:
+		j.State = JOB_SUCCEEDED
+	case status.Code(err) == codes.Canceled:
+		j.State = JOB_CANCELED
+	default:
+		j.State = JOB_FAILED
+		j.Error = status.Convert(err).Message()
+	}
+}
+
+// createJob starts a background job for the keys of the request body, or
+// for the range given by the min and max query params
+func createJob(c *gin.Context) {
+
+	var (
+		keys []string
+		err  error
+	)
+	if minP, maxP := c.Query("min"), c.Query("max"); minP != "" || maxP != "" {
+		keys, err = keyRange(minP, maxP)
+	} else {
+		keys, err = readKeys(c)
+	}
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	// jobs outlive the request that started them, a shutdown waits for
+	// them until its timeout. They call the servers with the credentials of
+	// the caller which created them.
+	parent := stopping
+	if md, ok := metadata.FromOutgoingContext(c.Request.Context()); ok {
+		parent = metadata.NewOutgoingContext(parent, md.Copy())
+	}
+	ctx, cancel := context.WithCancel(parent)
+	j := &job{
+		ID:        uuid.NewString(),
+		State:     JOB_RUNNING,
+		Total:     len(keys),
+		CreatedAt: time.Now(),
+		cancel:    cancel,
+		owner:     callerOf(c),
+	}
+	jobs.add(j)
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	detached.Add(1)
+	go func() {
+		defer detached.Done()
+		defer cancel()
+		j.run(ctx, xsvc, keys)
+	}()
+
+	c.Header("Location", "/jobs/"+j.ID)
+	c.JSON(http.StatusAccepted, j.snapshot())
+}
+
+// getJob returns the progress of a job
+func getJob(c *gin.Context) {
+	j, ok := jobs.get(c.Param("id"), callerOf(c))
+	if !ok {
+		renderError(c, status.Error(codes.NotFound, "job not found"))
+		return
+	}
+	c.JSON(http.StatusOK, j.snapshot())
+}
+
+// getJobSummary returns the XrefSummary of a finished job
+func getJobSummary(c *gin.Context) {
+	j, ok := jobs.get(c.Param("id"), callerOf(c))
+	if !ok {
+		renderError(c, status.Error(codes.NotFound, "job not found"))
+		return
+	}
+
+	j.mu.Lock()
+	summary := j.summary
+	j.mu.Unlock()
+	if summary == nil {
+		renderError(c, status.Error(codes.FailedPrecondition, "job is still running"))
+		return
+	}
+	renderProto(c, summary)
+}
+
+// cancelJob stops a running job, keeping what it allocated so far
+func cancelJob(c *gin.Context) {
+	j, ok := jobs.get(c.Param("id"), callerOf(c))
+	if !ok {
+		renderError(c, status.Error(codes.NotFound, "job not found"))
+		return
+	}
+
+	j.mu.Lock()
+	running := j.State == JOB_RUNNING
+	j.mu.Unlock()
+	if !running {
+		renderError(c, status.Error(codes.FailedPrecondition, "job already finished"))
+		return
+	}
+
+	j.cancel()
+	c.JSON(http.StatusAccepted, j.snapshot())
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	maxBody = flag.Int64("max-body", 32<<20, "max bytes of a request body posting keys or a csv upload")
+	maxKeys = flag.Int("max-keys", 100000, "max keys of a request body")
+)
+
+// limitBody caps the request body at -max-body bytes
+func limitBody(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, *maxBody)
+}
+
+// readKeys reads the keys of a request body, keeping each key exactly as
+// sent. The body may be
+//
+//	application/json     a JSON array of keys
+//	text/csv             csv rows, keys taken from the first column or the
+//	                     column named by the column query param
+//	multipart/form-data  a csv "file" upload, read as text/csv
+//	text/plain           one key per line
+//
+// Bodies are limited to -max-body bytes and -max-keys keys.
+func readKeys(c *gin.Context) ([]string, error) {
+
+	limitBody(c)
+
+	mediaType, _, err := mime.ParseMediaType(c.ContentType())
+	if err != nil && c.ContentType() != "" {
+		return nil, fmt.Errorf("invalid content type: %v", err)
+	}
+
+	var keys []string
+	switch mediaType {
+	case "", "application/json":
+		if err := json.NewDecoder(c.Request.Body).Decode(&keys); err != nil {
+			return nil, fmt.Errorf("body must be a JSON array of keys: %v", err)
+		}
+		if len(keys) > *maxKeys {
+			return nil, tooManyKeys(*maxKeys)
+		}
+	case "text/csv", "multipart/form-data":
+		body, _, cerr := uploadedCSV(c)
+		if cerr != nil {
+			return nil, cerr
+		}
+		keys, err = readCSVKeys(body, c.Query("column"), *maxKeys)
+	case "text/plain":
+		keys, err = readLineKeys(c.Request.Body, *maxKeys)
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no keys in request")
+	}
+	return keys, nil
+}
+
+// uploadedCSV returns the csv of a text/csv body or of the "file" part of a
+// multipart/form-data body, along with its file name when uploaded. The
+// upload is read as a stream rather than buffered.
+func uploadedCSV(c *gin.Context) (io.Reader, string, error) {
+
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case "text/csv":
+		return c.Request.Body, "", nil
+	case "multipart/form-data":
+		mr, err := c.Request.MultipartReader()
+		if err != nil {
+			return nil, "", err
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return nil, "", errors.New("missing csv file upload")
+			}
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid upload: %v", err)
+			}
+			if part.FormName() == "file" {
+				return part, part.FileName(), nil
+			}
+		}
+	default:
+		return nil, "", fmt.Errorf("unsupported content type %q, expected text/csv or multipart/form-data", mediaType)
+	}
+}
+
+// csvKeyColumn returns the index of the key column and the header row.
+// Without a column name the first column holds keys and there is no header
+// row.
+func csvKeyColumn(r *csv.Reader, column string) (int, []string, error) {
+	if column == "" {
+		return 0, nil, nil
+	}
+	header, err := r.Read()
+	if err != nil {
+		return 0, nil, fmt.Errorf("unable to read csv header: %v", err)
+	}
+	for i, name := range header {
+		if strings.TrimSpace(name) == column {
+			return i, header, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("csv has no %q column", column)
+}
+
+// tooManyKeys is the error of a body holding more than max keys
+func tooManyKeys(max int) error {
+	return fmt.Errorf("request holds more than %d keys", max)
+}
+
+func readCSVKeys(body io.Reader, column string, max int) ([]string, error) {
+
+	r := csv.NewReader(body)
+	r.FieldsPerRecord = -1
+	col, _, err := csvKeyColumn(r, column)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %v", err)
+		}
+		if col >= len(row) {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("csv line %d has no key column", line)
+		}
+		if len(keys) == max {
+			return nil, tooManyKeys(max)
+		}
+		keys = append(keys, row[col])
+	}
+}
+
+func readLineKeys(body io.Reader, max int) ([]string, error) {
+
+	var keys []string
+	s := bufio.NewScanner(body)
+	for s.Scan() {
+		if key := strings.TrimSpace(s.Text()); key != "" {
+			if len(keys) == max {
+				return nil, tooManyKeys(max)
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, s.Err()
+}
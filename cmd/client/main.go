@@ -4,15 +4,18 @@ import (
 	"context"
 	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
-	"time"
+	"syscall"
 
 	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
 	"github.com/gin-gonic/gin"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 const (
@@ -21,22 +24,41 @@ const (
 )
 
 var (
-	serverAddr = flag.String("grpcsvr", "localhost:50051", "grpc server address")
-	ctx        context.Context
+	serverAddr = flag.String("grpcsvr", "localhost:50051", "grpc server address, a comma separated list of addresses or a target such as dns:///xref:50051")
 )
 
 func main() {
 
 	flag.Parse()
 
-	// set up a connection to the server
-	conn, err := grpc.Dial(*serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// set up a connection balanced over the servers
+	target, opts, err := dialTarget(*serverAddr)
+	if err != nil {
+		log.Fatalf("unable to configure grpc connection: %v", err)
+	}
+	creds, err := transportCredentials()
+	if err != nil {
+		log.Fatalf("unable to configure grpc tls: %v", err)
+	}
+	opts = append(opts, grpc.WithTransportCredentials(creds))
+	perRPC, err := gatewayCredentials()
+	if err != nil {
+		log.Fatalf("unable to configure grpc credentials: %v", err)
+	}
+	if perRPC != nil {
+		if creds.Info().SecurityProtocol != "tls" {
+			if !*insecureCredentials {
+				log.Fatalf("credentials need -tls, or -insecure-credentials to send them over plaintext")
+			}
+			log.Printf("warning: sending credentials over plaintext")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(perRPC))
+	}
+	conn, err := grpc.Dial(target, opts...)
 	if err != nil {
 		log.Fatalf("unable to connect to grpc server: %v", err)
 	}
-	defer conn.Close()
 
-	ctx = context.Background()
 	xsvc := xref.NewXrefServiceClient(conn)
 
 	r := gin.Default()
@@ -45,17 +67,86 @@ func main() {
 		c.Set("grpcsvr", conn)
 		c.Set("xsvc", xsvc)
 	})
+	r.Use(forwardCredentials)
+
+	unary := *unaryTimeout
+	streaming := *streamTimeout
 
 	// grpc route group
 	rg := r.Group("/grpc")
 	{
-		rg.GET("/getxref/:num", getXref)                                // simple rpc
-		rg.GET("/addxrefs/:min/:max", addXrefs)                         // client streaming rpc
-		rg.GET("/getxrefs/:min/:max", getXrefs)                         // bidirectional streaming rpc
-		rg.GET("/getmagicnumbers/:status", getMagicNumbers)             // server streaming rpc
-		rg.GET("/getmagicnumbersummary/:status", getMagicNumberSummary) // simple rpc
+		rg.GET("/getxref/:num", deadline("getxref", unary), getXref)                                              // simple rpc
+		rg.GET("/addxrefs/:min/:max", deadline("addxrefs", streaming), addXrefs)                                  // client streaming rpc
+		rg.POST("/addxrefs", deadline("addxrefs", streaming), addXrefs)                                           // client streaming rpc
+		rg.GET("/getxrefs/:min/:max", deadline("getxrefs", streaming), getXrefs)                                  // bidirectional streaming rpc
+		rg.POST("/getxrefs", deadline("getxrefs", streaming), getXrefs)                                           // bidirectional streaming rpc
+		rg.GET("/ws/getxrefs", deadline("wsgetxrefs", 0), detach, until(stopping), getXrefsWS)                    // bidirectional streaming rpc
+		rg.POST("/tokenize", deadline("tokenize", streaming), tokenize)                                           // bidirectional streaming rpc
+		rg.GET("/getmagicnumbers/:status", deadline("getmagicnumbers", streaming), getMagicNumbers)               // server streaming rpc
+		rg.GET("/getmagicnumbersummary/:status", deadline("getmagicnumbersummary", unary), getMagicNumberSummary) // simple rpc
+		rg.GET("/getpoolstats", deadline("getpoolstats", unary), getPoolStats)                                    // simple rpc
+		rg.GET("/watchpoollevel", deadline("watchpoollevel", 0), until(draining), watchPoolLevel)                 // server streaming rpc
+		rg.GET("/watchxrefs", deadline("watchxrefs", 0), until(draining), watchXrefs)                             // server streaming rpc
+	}
+
+	// xref resources
+	r.POST("/xrefs", deadline("createxrefs", streaming), createXrefs)
+	r.GET("/xrefs/:key", deadline("readxref", unary), readXref)
+	r.DELETE("/xrefs/:key", deadline("deletexref", unary), deleteXref)
+
+	// background batch jobs, which run past the request starting them
+	r.POST("/jobs", createJob)
+	r.GET("/jobs/:id", getJob)
+	r.GET("/jobs/:id/summary", getJobSummary)
+	r.DELETE("/jobs/:id", cancelJob)
+
+	// routes bound by the google.api.http annotations of xref.proto
+	bindings, err := httpBindings()
+	if err != nil {
+		log.Fatalf("invalid http annotations: %v", err)
+	}
+	transcode(r, bindings, unary, streaming)
+
+	// probes
+	r.GET("/healthz", healthz)
+	r.GET("/readyz", readyz)
+
+	// api docs
+	r.GET("/openapi.json", serveOpenAPI(openAPI(bindings)))
+	r.GET("/docs", serveSwaggerUI)
+	r.GET("/docs/assets/*file", serveSwaggerAssets)
+
+	if err := checkRouteTimeouts(); err != nil {
+		log.Fatal(err)
+	}
+
+	srv := &http.Server{Addr: listenAddr(), Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+	log.Printf("gateway listening at %v", srv.Addr)
+
+	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	<-ctx.Done()
+	stopSignals() // a second signal kills the gateway
+
+	shutdown(srv)
+	if err := conn.Close(); err != nil {
+		log.Printf("failed to close grpc connection: %v", err)
 	}
-	r.Run()
+	log.Printf("gateway stopped")
+}
+
+// listenAddr is the address the gateway listens on, :8080 unless the PORT
+// environment variable is set
+func listenAddr() string {
+	if port := os.Getenv("PORT"); port != "" {
+		return ":" + port
+	}
+	return ":8080"
 }
 
 func getXref(c *gin.Context) {
@@ -66,37 +157,21 @@ func getXref(c *gin.Context) {
 	* gRPC *
 	********/
 
-	res, err := xsvc.GetXref(ctx, &xref.XrefRequest{
+	res, err := xsvc.GetXref(c.Request.Context(), &xref.XrefRequest{
 		Lastfour: lf,
 	})
 	if err != nil {
-		log.Printf("err: %v", err)
-	} else {
-		log.Println("received", res.Token.GetValue())
+		renderError(c, err)
+		return
 	}
+	renderProto(c, res)
 }
 
 func addXrefs(c *gin.Context) {
 
-	// get params and validate request
-	minP := c.Param("min")
-	maxP := c.Param("max")
-	if len(minP) < 4 || len(maxP) < 4 {
-		c.AbortWithError(500, errors.New("min 4 digit num"))
-		return
-	}
-	min, err := strconv.Atoi(c.Param("min"))
-	if err != nil {
-		c.AbortWithStatus(500)
-		return
-	}
-	max, err := strconv.Atoi(c.Param("max"))
-	if err != nil {
-		c.AbortWithStatus(500)
-		return
-	}
-	if max < min {
-		c.AbortWithError(500, errors.New("max must be > min"))
+	// get keys and validate request
+	keys, ok := requestKeys(c)
+	if !ok {
 		return
 	}
 
@@ -105,36 +180,62 @@ func addXrefs(c *gin.Context) {
 	********/
 
 	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
-	stream, err := xsvc.AddXrefs(ctx)
+	stream, err := xsvc.AddXrefs(c.Request.Context())
 	if err != nil {
-		log.Printf("error: %v", err)
+		renderError(c, err)
 		return
 	}
-	for i := min; i < max; i++ {
-		if err := stream.Send(&xref.XrefRequest{Lastfour: strconv.Itoa(i)}); err != nil {
-			return
+	for _, key := range keys {
+		if err := stream.Send(&xref.XrefRequest{Lastfour: key}); err != nil {
+			break // the server's error is returned by CloseAndRecv
 		}
 	}
 	res, err := stream.CloseAndRecv()
 	if err != nil {
+		renderError(c, err)
 		return
 	}
-
-	// event summary
-	log.Printf("\n\n******************************\n%-10s%10d\n%-10s%10d\n%-10s%15d\n******************************\n", "Total New", res.TotalNew, "Total Updated", res.TotalUpdated, "Elapsed Time", res.ElapsedTime)
+	renderProto(c, res)
 }
 
+// MAX_JSON_PAGE_SIZE bounds the magic numbers collected into a JSON array
+const MAX_JSON_PAGE_SIZE uint64 = 10000
+
 func getMagicNumbers(c *gin.Context) {
 
-	var s xref.Status_STATUS
-	statP := c.Param("status")
-	switch statP {
-	case AVAILABLE:
-		s = xref.Status_AVAILABLE
-	case UNAVAILABLE:
-		s = xref.Status_UNAVAILABLE
-	default:
-		c.AbortWithError(400, errors.New("unknown status requested"))
+	s, ok := parseStatus(c)
+	if !ok {
+		return
+	}
+
+	// optional paging and filtering
+	var pageSize uint64
+	if sizeP := c.Query("page_size"); sizeP != "" {
+		var err error
+		pageSize, err = strconv.ParseUint(sizeP, 10, 32)
+		if err != nil {
+			badRequest(c, "page_size must be a positive number")
+			return
+		}
+	}
+	details := false
+	if detailsP := c.Query("details"); detailsP != "" {
+		var err error
+		details, err = strconv.ParseBool(detailsP)
+		if err != nil {
+			badRequest(c, "details must be true or false")
+			return
+		}
+	}
+
+	// a JSON array is collected in memory before it is written, so only a
+	// bounded page of it is
+	format, ok := streamFormatOf(c, formatNDJSON)
+	if !ok {
+		return
+	}
+	if format == formatJSON && (pageSize == 0 || pageSize > MAX_JSON_PAGE_SIZE) {
+		badRequest(c, fmt.Sprintf("format json needs a page_size of 1 to %d", MAX_JSON_PAGE_SIZE))
 		return
 	}
 
@@ -143,44 +244,88 @@ func getMagicNumbers(c *gin.Context) {
 	********/
 
 	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
-	stream, err := xsvc.GetMagicNumbers(ctx, &xref.Status{Status: s})
+	stream, err := xsvc.GetMagicNumbers(c.Request.Context(), &xref.Status{
+		Status:         s,
+		PageSize:       uint32(pageSize),
+		PageToken:      c.Query("page_token"),
+		Prefix:         c.Query("prefix"),
+		IncludeDetails: details,
+	})
 	if err != nil {
+		renderError(c, err)
 		return
 	}
 
-	for {
-		magicNum, err := stream.Recv()
-		if err == io.EOF {
-			break
-		}
+	forwardStream(c, format, "magicnumber", stream.Recv, nil)
+}
+
+func watchPoolLevel(c *gin.Context) {
+
+	changesOnly := false
+	if changesP := c.Query("changes_only"); changesP != "" {
+		var err error
+		changesOnly, err = strconv.ParseBool(changesP)
 		if err != nil {
+			badRequest(c, "changes_only must be true or false")
 			return
 		}
-		log.Println(magicNum.Value)
 	}
+	format, ok := streamFormatOf(c, formatNDJSON)
+	if !ok {
+		return
+	}
+	if format == formatJSON {
+		badRequest(c, "watch streams are ndjson or sse")
+		return
+	}
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	stream, err := xsvc.WatchPoolLevel(c.Request.Context(), &xref.PoolLevelRequest{ChangesOnly: changesOnly})
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	forwardStream(c, format, "poollevel", stream.Recv, nil)
 }
 
-func getXrefs(c *gin.Context) {
+func watchXrefs(c *gin.Context) {
 
-	// get params and validate request
-	minP := c.Param("min")
-	maxP := c.Param("max")
-	if len(minP) < 4 || len(maxP) < 4 {
-		c.AbortWithError(500, errors.New("min 4 digit num"))
+	// event source clients resume with the Last-Event-ID header
+	lastID := c.Query("last_event_id")
+	if lastID == "" {
+		lastID = c.GetHeader("Last-Event-ID")
+	}
+	format, ok := streamFormatOf(c, formatNDJSON)
+	if !ok {
 		return
 	}
-	min, err := strconv.Atoi(c.Param("min"))
-	if err != nil {
-		c.AbortWithStatus(500)
+	if format == formatJSON {
+		badRequest(c, "watch streams are ndjson or sse")
 		return
 	}
-	max, err := strconv.Atoi(c.Param("max"))
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	stream, err := xsvc.WatchXrefs(c.Request.Context(), &xref.WatchXrefsRequest{LastEventId: lastID})
 	if err != nil {
-		c.AbortWithStatus(500)
+		renderError(c, err)
 		return
 	}
-	if max < min {
-		c.AbortWithError(500, errors.New("max must be > min"))
+	forwardStream(c, format, "xref", stream.Recv, (*xref.XrefEvent).GetId)
+}
+
+func getXrefs(c *gin.Context) {
+
+	// get keys and validate request
+	keys, ok := requestKeys(c)
+	if !ok {
 		return
 	}
 
@@ -188,53 +333,53 @@ func getXrefs(c *gin.Context) {
 	* gRPC *
 	********/
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	xrefs, err := exchangeXrefs(c.Request.Context(), xsvc, keys)
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	renderProtos(c, http.StatusOK, xrefs)
+}
+
+// exchangeXrefs sends keys over a GetXrefs stream while collecting the
+// xrefs sent back
+func exchangeXrefs(ctx context.Context, xsvc xref.XrefServiceClient, keys []string) ([]*xref.XrefResponse, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	stream, err := xsvc.GetXrefs(ctx)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	waitc := make(chan struct{})
 	go func() {
-		for {
-			in, err := stream.Recv()
-			if err == io.EOF {
-				close(waitc)
-				return
-			}
-			if err != nil {
-				return
+		for _, key := range keys {
+			if err := stream.Send(&xref.XrefRequest{Lastfour: key}); err != nil {
+				return // the server's error is returned by Recv
 			}
-			log.Println("received", in.Token.GetValue())
-
 		}
+		stream.CloseSend()
 	}()
 
-	for i := min; i < max; i++ {
-		if err := stream.Send(&xref.XrefRequest{Lastfour: strconv.Itoa(i)}); err != nil {
-			return
+	xrefs := make([]*xref.XrefResponse, 0, len(keys))
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return xrefs, nil
 		}
+		if err != nil {
+			return nil, err
+		}
+		xrefs = append(xrefs, in)
 	}
-
-	stream.CloseSend()
-	<-waitc
 }
 
 func getMagicNumberSummary(c *gin.Context) {
 
-	var s xref.Status_STATUS
-	statP := c.Param("status")
-	switch statP {
-	case AVAILABLE:
-		s = xref.Status_AVAILABLE
-	case UNAVAILABLE:
-		s = xref.Status_UNAVAILABLE
-	default:
-		c.AbortWithError(400, errors.New("unknown status requested"))
+	s, ok := parseStatus(c)
+	if !ok {
 		return
 	}
 
@@ -243,9 +388,93 @@ func getMagicNumberSummary(c *gin.Context) {
 	********/
 
 	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
-	summary, err := xsvc.GetMagicNumberSummary(ctx, &xref.Status{Status: s})
+	summary, err := xsvc.GetMagicNumberSummary(c.Request.Context(), &xref.Status{Status: s})
 	if err != nil {
+		renderError(c, err)
 		return
 	}
-	log.Printf("Total %s: %d", statP, summary.Total)
+	renderProto(c, summary)
+}
+
+func getPoolStats(c *gin.Context) {
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	stats, err := xsvc.GetPoolStats(c.Request.Context(), &xref.PoolStatsRequest{})
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	renderProto(c, stats)
+}
+
+// requestKeys returns the keys of a request, read from the body of a POST
+// or built from the min and max params
+func requestKeys(c *gin.Context) ([]string, bool) {
+	if c.Request.Method == http.MethodPost {
+		keys, err := readKeys(c)
+		if err != nil {
+			badRequest(c, err.Error())
+			return nil, false
+		}
+		return keys, true
+	}
+	return rangeKeys(c)
+}
+
+// rangeKeys validates the min and max params, returning the keys from min
+// up to max
+func rangeKeys(c *gin.Context) ([]string, bool) {
+	keys, err := keyRange(c.Param("min"), c.Param("max"))
+	if err != nil {
+		badRequest(c, err.Error())
+		return nil, false
+	}
+	return keys, true
+}
+
+// MAX_RANGE_KEYS bounds the keys a single min/max range asks for
+const MAX_RANGE_KEYS int = 10000
+
+// keyRange returns the keys from min up to max padded to the width of min
+func keyRange(minP, maxP string) ([]string, error) {
+	if len(minP) < 4 || len(maxP) < 4 {
+		return nil, errors.New("min 4 digit num")
+	}
+	min, err := strconv.Atoi(minP)
+	if err != nil {
+		return nil, errors.New("min must be a number")
+	}
+	max, err := strconv.Atoi(maxP)
+	if err != nil {
+		return nil, errors.New("max must be a number")
+	}
+	if max < min {
+		return nil, errors.New("max must be > min")
+	}
+	if max-min > MAX_RANGE_KEYS {
+		return nil, fmt.Errorf("range must hold at most %d keys", MAX_RANGE_KEYS)
+	}
+
+	keys := make([]string, 0, max-min)
+	for i := min; i < max; i++ {
+		keys = append(keys, fmt.Sprintf("%0*d", len(minP), i))
+	}
+	return keys, nil
+}
+
+// parseStatus validates the status param
+func parseStatus(c *gin.Context) (xref.Status_STATUS, bool) {
+	switch c.Param("status") {
+	case AVAILABLE:
+		return xref.Status_AVAILABLE, true
+	case UNAVAILABLE:
+		return xref.Status_UNAVAILABLE, true
+	default:
+		badRequest(c, "unknown status requested")
+		return 0, false
+	}
 }
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schema is an OpenAPI schema object
+type schema map[string]interface{}
+
+// ref points at a component schema
+func ref(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+// arrayOf is a JSON array of items
+func arrayOf(items schema) schema {
+	return schema{"type": "array", "items": items}
+}
+
+// messageRef points at the component schema of a proto message
+func messageRef(m protoreflect.ProtoMessage) schema {
+	return ref(string(m.ProtoReflect().Descriptor().FullName()))
+}
+
+// apiParam is a path or query parameter of a route
+type apiParam struct {
+	name, in, desc string
+	schema         schema
+}
+
+func pathParam(name, desc string) apiParam {
+	return apiParam{name: name, in: "path", desc: desc, schema: schema{"type": "string"}}
+}
+
+func queryParam(name, desc string, s schema) apiParam {
+	return apiParam{name: name, in: "query", desc: desc, schema: s}
+}
+
+// apiRoute describes a gateway route
+type apiRoute struct {
+	method, path, summary string
+	params                []apiParam
+	// body maps request content types to their schema
+	body         map[string]schema
+	optionalBody bool
+	// status and content describe the successful response, status
+	// defaults to 200
+	status  int
+	content map[string]schema
+}
+
+var (
+	stringSchema = schema{"type": "string"}
+	boolSchema   = schema{"type": "boolean"}
+	keysSchema   = arrayOf(schema{"type": "string", "example": "0042"})
+	csvSchema    = schema{"type": "string", "description": "csv rows"}
+	uploadSchema = schema{
+		"type":       "object",
+		"properties": schema{"file": schema{"type": "string", "format": "binary"}},
+		"required":   []string{"file"},
+	}
+
+	// keysBody are the ways a list of keys can be sent
+	keysBody = map[string]schema{
+		"application/json":    keysSchema,
+		"text/csv":            csvSchema,
+		"multipart/form-data": uploadSchema,
+		"text/plain":          schema{"type": "string", "description": "one key per line"},
+	}
+
+	statusParam = apiParam{name: "status", in: "path", schema: schema{"type": "string", "enum": []string{AVAILABLE, UNAVAILABLE}}}
+	columnParam = queryParam("column", "name of the key column, the csv then has a header row", stringSchema)
+	formatParam = queryParam("format", "response format, also negotiated with the Accept header", schema{"type": "string", "enum": []string{"json", "ndjson", "sse"}})
+)
+
+func jsonContent(s schema) map[string]schema {
+	return map[string]schema{"application/json": s}
+}
+
+// streamContent is a server stream of items as JSON, NDJSON or events
+func streamContent(item schema, withJSON bool) map[string]schema {
+	c := map[string]schema{
+		ndjsonContentType:   item,
+		"text/event-stream": schema{"type": "string", "description": "one event per message, data holds the JSON message"},
+	}
+	if withJSON {
+		c["application/json"] = arrayOf(item)
+	}
+	return c
+}
+
+// apiRoutes are the documented gateway routes
+var apiRoutes = []apiRoute{
+	{
+		method: "get", path: "/grpc/getxref/{num}", summary: "Get or allocate the xref of a key",
+		params:  []apiParam{pathParam("num", "4 character key")},
+		content: jsonContent(messageRef(&xref.XrefResponse{})),
+	},
+	{
+		method: "get", path: "/grpc/addxrefs/{min}/{max}", summary: "Allocate xrefs for a range of keys",
+		params:  []apiParam{pathParam("min", "first key, at least 4 digits"), pathParam("max", "key after the last")},
+		content: jsonContent(messageRef(&xref.XrefSummary{})),
+	},
+	{
+		method: "post", path: "/grpc/addxrefs", summary: "Allocate xrefs for a list of keys",
+		params:  []apiParam{columnParam},
+		body:    keysBody,
+		content: jsonContent(messageRef(&xref.XrefSummary{})),
+	},
+	{
+		method: "get", path: "/grpc/getxrefs/{min}/{max}", summary: "Get or allocate xrefs for a range of keys",
+		params:  []apiParam{pathParam("min", "first key, at least 4 digits"), pathParam("max", "key after the last")},
+		content: jsonContent(arrayOf(messageRef(&xref.XrefResponse{}))),
+	},
+	{
+		method: "post", path: "/grpc/getxrefs", summary: "Get or allocate xrefs for a list of keys",
+		params:  []apiParam{columnParam},
+		body:    keysBody,
+		content: jsonContent(arrayOf(messageRef(&xref.XrefResponse{}))),
+	},
+	{
+		method: "get", path: "/grpc/ws/getxrefs", summary: "Websocket exchanging keys for XrefResponse frames",
+		status: http.StatusSwitchingProtocols,
+	},
+	{
+		method: "post", path: "/grpc/tokenize", summary: "Append xref and error columns to an uploaded csv",
+		params: []apiParam{columnParam},
+		body: map[string]schema{
+			"text/csv":            csvSchema,
+			"multipart/form-data": uploadSchema,
+		},
+		content: map[string]schema{"text/csv": csvSchema},
+	},
+	{
+		method: "get", path: "/grpc/getmagicnumbers/{status}", summary: "List magic numbers",
+		params: []apiParam{
+			statusParam,
+			queryParam("page_size", "max magic numbers to return, 0 returns all, required with format json", schema{"type": "integer", "minimum": 0}),
+			queryParam("page_token", "page token of the last magic number received", stringSchema),
+			queryParam("prefix", "only magic numbers starting with prefix", stringSchema),
+			queryParam("details", "include the xref mapping of each magic number", boolSchema),
+			formatParam,
+		},
+		content: streamContent(messageRef(&xref.MagicNumber{}), true),
+	},
+	{
+		method: "get", path: "/grpc/getmagicnumbersummary/{status}", summary: "Count magic numbers",
+		params:  []apiParam{statusParam},
+		content: jsonContent(messageRef(&xref.MagicNumberSummary{})),
+	},
+	{
+		method: "get", path: "/grpc/getpoolstats", summary: "Pool statistics",
+		content: jsonContent(messageRef(&xref.PoolStats{})),
+	},
+	{
+		method: "get", path: "/grpc/watchpoollevel", summary: "Stream pool level updates",
+		params: []apiParam{
+			queryParam("changes_only", "only stream updates where the level changed", boolSchema),
+			formatParam,
+		},
+		content: streamContent(messageRef(&xref.PoolLevel{}), false),
+	},
+	{
+		method: "get", path: "/grpc/watchxrefs", summary: "Stream xref events",
+		params: []apiParam{
+			queryParam("last_event_id", "resume after this event id, the Last-Event-ID header is used when unset", stringSchema),
+			formatParam,
+		},
+		content: streamContent(messageRef(&xref.XrefEvent{}), false),
+	},
+	{
+		method: "post", path: "/xrefs", summary: "Allocate xrefs for a list of keys",
+		params:  []apiParam{columnParam},
+		body:    keysBody,
+		content: jsonContent(arrayOf(messageRef(&xref.XrefResponse{}))),
+	},
+	{
+		method: "get", path: "/xrefs/{key}", summary: "Read the xref of a key without allocating",
+		params:  []apiParam{pathParam("key", "4 character key")},
+		content: jsonContent(messageRef(&xref.XrefResponse{})),
+	},
+	{
+		method: "delete", path: "/xrefs/{key}", summary: "Delete the xref of a key, retiring its magic number",
+		params: []apiParam{pathParam("key", "4 character key")},
+		status: http.StatusNoContent,
+	},
+	{
+		method: "post", path: "/jobs", summary: "Allocate xrefs in a background job",
+		params: []apiParam{
+			queryParam("min", "first key of a range, instead of a body", stringSchema),
+			queryParam("max", "key after the last of a range", stringSchema),
+			columnParam,
+		},
+		body:         keysBody,
+		optionalBody: true,
+		status:       http.StatusAccepted,
+		content:      jsonContent(ref("Job")),
+	},
+	{
+		method: "get", path: "/jobs/{id}", summary: "Job progress",
+		params:  []apiParam{pathParam("id", "job id")},
+		content: jsonContent(ref("Job")),
+	},
+	{
+		method: "get", path: "/jobs/{id}/summary", summary: "Summary of a finished job",
+		params:  []apiParam{pathParam("id", "job id")},
+		content: jsonContent(messageRef(&xref.XrefSummary{})),
+	},
+	{
+		method: "delete", path: "/jobs/{id}", summary: "Cancel a running job",
+		params:  []apiParam{pathParam("id", "job id")},
+		status:  http.StatusAccepted,
+		content: jsonContent(ref("Job")),
+	},
+	{
+		method: "get", path: "/healthz", summary: "Liveness probe",
+		content: jsonContent(schema{"type": "object", "properties": schema{"status": stringSchema}}),
+	},
+	{
+		method: "get", path: "/readyz", summary: "Readiness probe, 503 when the servers can't be reached or aren't serving",
+		content: jsonContent(ref("Readiness")),
+	},
+}
+
+// gatewaySchemas are the schemas of responses not built from proto messages
+var gatewaySchemas = map[string]schema{
+	"Error": {
+		"type": "object",
+		"properties": schema{
+			"error": schema{
+				"type": "object",
+				"properties": schema{
+					"code":    schema{"type": "integer"},
+					"status":  schema{"type": "string", "example": "INVALID_ARGUMENT"},
+					"message": stringSchema,
+				},
+			},
+		},
+	},
+	"Job": {
+		"type": "object",
+		"properties": schema{
+			"id":          stringSchema,
+			"state":       schema{"type": "string", "enum": []string{JOB_RUNNING, JOB_SUCCEEDED, JOB_FAILED, JOB_CANCELED}},
+			"total":       schema{"type": "integer"},
+			"processed":   schema{"type": "integer"},
+			"new":         schema{"type": "integer"},
+			"existing":    schema{"type": "integer"},
+			"failed":      schema{"type": "integer"},
+			"error":       stringSchema,
+			"created_at":  schema{"type": "string", "format": "date-time"},
+			"finished_at": schema{"type": "string", "format": "date-time"},
+		},
+	},
+	"Readiness": {
+		"type": "object",
+		"properties": schema{
+			"ready":        boolSchema,
+			"connectivity": schema{"type": "string", "enum": []string{"IDLE", "CONNECTING", "READY", "TRANSIENT_FAILURE", "SHUTDOWN"}},
+			"serving":      schema{"type": "string", "enum": []string{"UNKNOWN", "SERVING", "NOT_SERVING", "SERVICE_UNKNOWN", "UNIMPLEMENTED"}},
+			"error":        stringSchema,
+		},
+	},
+}
+
+// messageSchemas adds the schema of md, and of every message it refers to,
+// following the protojson mapping
+func messageSchemas(md protoreflect.MessageDescriptor, schemas map[string]schema) {
+	name := string(md.FullName())
+	if _, ok := schemas[name]; ok {
+		return
+	}
+
+	props := schema{}
+	schemas[name] = schema{"type": "object", "properties": props}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		fs := fieldSchema(fd, schemas)
+		if fd.IsList() {
+			fs = arrayOf(fs)
+		}
+		props[fd.JSONName()] = fs
+	}
+}
+
+// fieldSchema returns the schema of a single field value
+func fieldSchema(fd protoreflect.FieldDescriptor, schemas map[string]schema) schema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return boolSchema
+	case protoreflect.StringKind:
+		return stringSchema
+	case protoreflect.BytesKind:
+		return schema{"type": "string", "format": "byte"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return schema{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return schema{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson writes 64 bit integers as strings
+		return schema{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return schema{"type": "number"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return schema{"type": "string", "enum": names}
+	}
+
+	// well known types have their own JSON forms
+	md := fd.Message()
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		return schema{"type": "string", "format": "date-time"}
+	case "google.protobuf.Duration":
+		return schema{"type": "string", "example": "1.5s"}
+	case "google.protobuf.Any":
+		return schema{"type": "object"}
+	}
+	messageSchemas(md, schemas)
+	return ref(string(md.FullName()))
+}
+
+// openAPI builds the OpenAPI document of the gateway, including the routes
+// of bindings
+func openAPI(bindings []*httpBinding) gin.H {
+
+	schemas := map[string]schema{}
+	for name, s := range gatewaySchemas {
+		schemas[name] = s
+	}
+	messages := xref.File_xref_xref_proto.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		messageSchemas(messages.Get(i), schemas)
+	}
+
+	errorResponse := schema{
+		"description": "error",
+		"content":     jsonContent(ref("Error")),
+	}
+
+	routes := append([]apiRoute{}, apiRoutes...)
+	for _, b := range bindings {
+		routes = append(routes, b.apiRoute())
+	}
+
+	paths := map[string]schema{}
+	for _, r := range routes {
+		op := schema{"summary": r.summary}
+
+		var params []schema
+		for _, p := range r.params {
+			param := schema{"name": p.name, "in": p.in, "schema": p.schema}
+			if p.desc != "" {
+				param["description"] = p.desc
+			}
+			if p.in == "path" {
+				param["required"] = true
+			}
+			params = append(params, param)
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if r.body != nil {
+			content := schema{}
+			for ct, s := range r.body {
+				content[ct] = schema{"schema": s}
+			}
+			op["requestBody"] = schema{"required": !r.optionalBody, "content": content}
+		}
+
+		code := r.status
+		if code == 0 {
+			code = http.StatusOK
+		}
+		ok := schema{"description": http.StatusText(code)}
+		if r.content != nil {
+			content := schema{}
+			for ct, s := range r.content {
+				content[ct] = schema{"schema": s}
+			}
+			ok["content"] = content
+		}
+		op["responses"] = schema{strconv.Itoa(code): ok, "default": errorResponse}
+
+		if paths[r.path] == nil {
+			paths[r.path] = schema{}
+		}
+		paths[r.path][r.method] = op
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "xref gateway",
+			"description": "HTTP gateway to the xref gRPC service",
+			"version":     "1.0.0",
+		},
+		"paths":      paths,
+		"components": gin.H{"schemas": schemas},
+	}
+}
+
+// serveOpenAPI serves the OpenAPI document
+func serveOpenAPI(doc gin.H) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// swaggerUI is an interactive page for the OpenAPI document. Its assets are
+// served from the binary, pinned by go.sum, so the page loads nothing from
+// third parties.
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>xref gateway</title>
+  <link rel="stylesheet" href="/docs/assets/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/docs/assets/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+func serveSwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
+
+// swaggerAssets are the swagger-ui files the page loads
+var swaggerAssets = map[string]bool{
+	"swagger-ui.css":       true,
+	"swagger-ui-bundle.js": true,
+}
+
+// serveSwaggerAssets serves the swagger-ui files embedded in the binary
+func serveSwaggerAssets(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("file"), "/")
+	if !swaggerAssets[name] {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.FileFromFS(name, http.FS(swaggerFiles.FS))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/code"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// marshaler renders protobuf messages with every field present
+var marshaler = protojson.MarshalOptions{EmitUnpopulated: true}
+
+// errorBody is the JSON body of every failed request
+type errorBody struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// httpStatus maps a gRPC status code to its HTTP status
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable, codes.ResourceExhausted:
+		// the servers run out of magic numbers rather than rate limit, no
+		// client backing off helps
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorBodyOf returns the HTTP status and error body for a gRPC error
+func errorBodyOf(err error) (int, errorBody) {
+	s := status.Convert(err)
+	httpCode := httpStatus(s.Code())
+	return httpCode, errorBody{Error: errorDetail{
+		Code:    httpCode,
+		Status:  code.Code(s.Code()).String(),
+		Message: s.Message(),
+	}}
+}
+
+// renderError aborts the request with the HTTP status and error body for a
+// gRPC error
+func renderError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(errorBodyOf(err))
+}
+
+// badRequest aborts the request with a 400 error body
+func badRequest(c *gin.Context, msg string) {
+	renderError(c, status.Error(codes.InvalidArgument, msg))
+}
+
+// renderProto writes m as the JSON response
+func renderProto(c *gin.Context, m proto.Message) {
+	b, err := marshaler.Marshal(m)
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", b)
+}
+
+// renderProtos writes ms as a JSON array response with status code
+func renderProtos[M proto.Message](c *gin.Context, code int, ms []M) {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, m := range ms {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		b, err := marshaler.Marshal(m)
+		if err != nil {
+			renderError(c, err)
+			return
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte(']')
+	c.Data(code, "application/json", buf.Bytes())
+}
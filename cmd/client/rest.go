@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+)
+
+// createXrefs allocates xrefs for a list of keys, returning the xref of
+// each key in order. The status is 201 when any xref was allocated and 200
+// when every key already had one.
+func createXrefs(c *gin.Context) {
+
+	keys, err := readKeys(c)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	xrefs, err := exchangeXrefs(c.Request.Context(), xsvc, keys)
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	code := http.StatusOK
+	for _, x := range xrefs {
+		if x.GetCreated() {
+			code = http.StatusCreated
+			break
+		}
+	}
+	renderProtos(c, code, xrefs)
+}
+
+// readXref returns the xref of a key without allocating one
+func readXref(c *gin.Context) {
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	res, err := xsvc.LookupXref(c.Request.Context(), &xref.XrefRequest{Lastfour: c.Param("key")})
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	renderProto(c, res)
+}
+
+// deleteXref removes the xref of a key
+func deleteXref(c *gin.Context) {
+
+	/*******
+	* gRPC *
+	********/
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	if _, err := xsvc.DeleteXref(c.Request.Context(), &xref.XrefRequest{Lastfour: c.Param("key")}); err != nil {
+		renderError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	shutdownTimeout = flag.Duration("shutdown-timeout", 30*time.Second, "time requests and jobs in flight have to finish on SIGINT or SIGTERM")
+)
+
+var (
+	// draining is cancelled when the gateway starts shutting down, ending
+	// watches which never end on their own
+	draining, drain = context.WithCancel(context.Background())
+
+	// stopping is cancelled once the shutdown timeout passed, ending
+	// whatever still runs
+	stopping, stop = context.WithCancel(context.Background())
+
+	// detached tracks work http.Server.Shutdown doesn't wait for, hijacked
+	// websocket connections and background jobs
+	detached sync.WaitGroup
+)
+
+// until cancels the request context of a route once done is
+func until(done context.Context) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-done.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// detach tracks a route whose connection is hijacked, so is not waited for
+// by http.Server.Shutdown
+func detach(c *gin.Context) {
+	detached.Add(1)
+	defer detached.Done()
+	c.Next()
+}
+
+// shutdown stops srv taking requests and waits for those in flight,
+// websockets and jobs included, cancelling them once the shutdown timeout
+// passed
+func shutdown(srv *http.Server) {
+
+	log.Printf("shutting down, waiting up to %v for requests in flight", *shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	// watches end as soon as the shutdown starts
+	srv.RegisterOnShutdown(drain)
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("shutdown timeout, closing requests in flight")
+		srv.Close()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		detached.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("shutdown timeout, cancelling websockets and jobs in flight")
+		stop()
+		<-done
+	}
+}
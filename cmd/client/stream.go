@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// streamFormat is how messages of a server stream are written to the
+// HTTP response
+type streamFormat int
+
+const (
+	// formatJSON collects the stream into a JSON array
+	formatJSON streamFormat = iota
+	// formatNDJSON writes one JSON message per line
+	formatNDJSON
+	// formatSSE writes one server-sent event per message
+	formatSSE
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// streamFormatOf picks the response format from the format query param or
+// the Accept header, falling back to def
+func streamFormatOf(c *gin.Context, def streamFormat) (streamFormat, bool) {
+	switch c.Query("format") {
+	case "json":
+		return formatJSON, true
+	case "ndjson":
+		return formatNDJSON, true
+	case "sse":
+		return formatSSE, true
+	case "":
+	default:
+		badRequest(c, "format must be json, ndjson or sse")
+		return 0, false
+	}
+
+	accept := c.GetHeader("Accept")
+	switch {
+	case strings.Contains(accept, sse.ContentType):
+		return formatSSE, true
+	case strings.Contains(accept, ndjsonContentType):
+		return formatNDJSON, true
+	default:
+		return def, true
+	}
+}
+
+// forwardStream writes the messages returned by recv to the response until
+// the stream ends. Once streaming has started errors are written in band,
+// as a final error line or an "error" event. Event ids are taken from id
+// when set.
+func forwardStream[M proto.Message](c *gin.Context, format streamFormat, event string, recv func() (M, error), id func(M) string) {
+
+	if format == formatJSON {
+		var ms []M
+		for {
+			m, err := recv()
+			if err == io.EOF {
+				renderProtos(c, http.StatusOK, ms)
+				return
+			}
+			if err != nil {
+				renderError(c, err)
+				return
+			}
+			ms = append(ms, m)
+		}
+	}
+
+	started := false
+	for {
+		m, err := recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// the client went away, there is no one to tell
+			if c.Request.Context().Err() != nil {
+				return
+			}
+			if !started {
+				renderError(c, err)
+				return
+			}
+			writeStreamError(c, format, err)
+			return
+		}
+
+		b, err := marshaler.Marshal(m)
+		if err != nil {
+			writeStreamError(c, format, err)
+			return
+		}
+
+		if !started {
+			started = true
+			h := c.Writer.Header()
+			h.Set("Cache-Control", "no-cache")
+			h.Set("X-Accel-Buffering", "no")
+			if format == formatSSE {
+				h.Set("Content-Type", sse.ContentType)
+			} else {
+				h.Set("Content-Type", ndjsonContentType)
+			}
+			c.Status(http.StatusOK)
+		}
+
+		if format == formatSSE {
+			e := sse.Event{Event: event, Data: string(b)}
+			if id != nil {
+				e.Id = id(m)
+			}
+			c.Render(-1, e)
+		} else {
+			c.Writer.Write(b)
+			c.Writer.Write([]byte{'\n'})
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeStreamError ends a started stream with the error body of err
+func writeStreamError(c *gin.Context, format streamFormat, err error) {
+	_, body := errorBodyOf(err)
+	b, _ := json.Marshal(body)
+
+	if format == formatSSE {
+		c.Render(-1, sse.Event{Event: "error", Data: string(b)})
+	} else {
+		c.Writer.Write(b)
+		c.Writer.Write([]byte{'\n'})
+	}
+	c.Writer.Flush()
+}
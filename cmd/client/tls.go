@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/tlsutil"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	useTLS        = flag.Bool("tls", false, "connect to the grpc servers over TLS, implied by the other tls flags")
+	tlsCA         = flag.String("tls-ca", "", "CA bundle verifying the grpc servers, the system roots when unset")
+	tlsCert       = flag.String("tls-cert", "", "client certificate file, for servers verifying clients")
+	tlsKey        = flag.String("tls-key", "", "client private key file")
+	tlsServerName = flag.String("tls-server-name", "", "name verified in server certificates, the host dialed when unset")
+)
+
+// transportCredentials secures the connection to the grpc servers when TLS
+// is asked for. Certificate files are reloaded when they change.
+func transportCredentials() (credentials.TransportCredentials, error) {
+
+	if !*useTLS && *tlsCA == "" && *tlsCert == "" && *tlsKey == "" && *tlsServerName == "" {
+		return insecure.NewCredentials(), nil
+	}
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return nil, errors.New("tls-cert and tls-key must be set together")
+	}
+	certs, err := tlsutil.NewReloader(*tlsCert, *tlsKey, *tlsCA)
+	if err != nil {
+		return nil, err
+	}
+	return tlsutil.ClientCredentials(certs, *tlsServerName), nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// tokenizeQueue is the number of rows sent to GetXrefs ahead of the rows
+	// written back
+	tokenizeQueue = 1024
+
+	// tokenizeFlush is the number of rows written between flushes
+	tokenizeFlush = 100
+)
+
+// tokenizeRow is a csv row waiting on its xref
+type tokenizeRow struct {
+	fields []string
+	// err is set for rows never sent to GetXrefs
+	err string
+}
+
+// tokenize streams the key column of an uploaded csv through GetXrefs,
+// streaming back the csv with xref and error columns appended. The key
+// column is the first one unless named by the column query param, in which
+// case the csv has a header row. Uploads are limited to -max-body bytes.
+func tokenize(c *gin.Context) {
+
+	limitBody(c)
+	body, filename, err := uploadedCSV(c)
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	// HTTP/1.x request bodies can't be read once the response is flushed,
+	// so the upload is spooled to disk before streaming the download
+	spool, err := os.CreateTemp("", "tokenize-*.csv")
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+	if _, err := io.Copy(spool, body); err != nil {
+		badRequest(c, fmt.Sprintf("unable to read upload: %v", err))
+		return
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		renderError(c, err)
+		return
+	}
+
+	r := csv.NewReader(spool)
+	r.FieldsPerRecord = -1
+	col, header, err := csvKeyColumn(r, c.Query("column"))
+	if err != nil {
+		badRequest(c, err.Error())
+		return
+	}
+
+	/*******
+	* gRPC *
+	********/
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+	stream, err := xsvc.GetXrefs(ctx)
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+
+	// rows are queued in the order their keys are sent, which is the order
+	// xrefs come back in
+	queue := make(chan tokenizeRow, tokenizeQueue)
+	go func() {
+		defer close(queue)
+		defer stream.CloseSend()
+		for {
+			row, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				queue <- tokenizeRow{err: fmt.Sprintf("invalid csv: %v", err)}
+				return
+			}
+			if col >= len(row) {
+				queue <- tokenizeRow{fields: row, err: "missing key column"}
+				continue
+			}
+
+			queue <- tokenizeRow{fields: row}
+			if err := stream.Send(&xref.XrefRequest{Lastfour: row[col]}); err != nil {
+				return // the server's error is returned by Recv
+			}
+		}
+	}()
+
+	if filename == "" {
+		filename = "xrefs.csv"
+	}
+	filename = strings.TrimSuffix(filename, ".csv") + "-xrefs.csv"
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	// rows are padded so the xref and error columns line up
+	width := col + 1
+	if header != nil {
+		width = len(header)
+		w.Write(append(header, "xref", "error"))
+	}
+
+	n := 0
+	for row := range queue {
+		xrefVal, errMsg := "", row.err
+		if errMsg == "" {
+			res, err := stream.Recv()
+			if err != nil {
+				// the stream failed, the rest of the rows share its error
+				_, body := errorBodyOf(err)
+				errMsg = body.Error.Message
+				cancel()
+			} else if res.GetError() != nil {
+				errMsg = res.GetError().GetMessage()
+			} else {
+				xrefVal = res.GetToken().GetValue()
+			}
+		}
+
+		w.Write(append(padRow(row.fields, width), xrefVal, errMsg))
+		if n++; n%tokenizeFlush == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	w.Flush()
+}
+
+// padRow returns fields with empty fields appended up to width
+func padRow(fields []string, width int) []string {
+	for len(fields) < width {
+		fields = append(fields, "")
+	}
+	return fields
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// httpBinding is an rpc bound to an HTTP route by its google.api.http
+// annotation
+type httpBinding struct {
+	method string
+	// path is the annotation's path template, e.g. /v1/xrefs/{lastfour}
+	path string
+	// pathFields are the request fields bound to path segments
+	pathFields []string
+	// body is "*" when the body is the request, or the request field it
+	// is bound to. Fields of requests without one are bound to query params.
+	body string
+	// responseBody is the response field written instead of the response
+	responseBody string
+
+	rpc        protoreflect.MethodDescriptor
+	fullMethod string
+	input      protoreflect.MessageType
+	output     protoreflect.MessageType
+}
+
+// templateVar matches the variables of a path template
+var templateVar = regexp.MustCompile(`\{([^{}=]*)\}`)
+
+// httpBindings reads the HTTP bindings of the xref service's rpcs
+func httpBindings() ([]*httpBinding, error) {
+
+	var bindings []*httpBinding
+	services := xref.File_xref_xref_proto.Services()
+	for i := 0; i < services.Len(); i++ {
+		sd := services.Get(i)
+		methods := sd.Methods()
+		for j := 0; j < methods.Len(); j++ {
+			md := methods.Get(j)
+			rule, _ := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if rule == nil {
+				continue
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				b, err := newHTTPBinding(sd, md, r)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", md.FullName(), err)
+				}
+				bindings = append(bindings, b)
+			}
+		}
+	}
+	return bindings, nil
+}
+
+func newHTTPBinding(sd protoreflect.ServiceDescriptor, md protoreflect.MethodDescriptor, rule *annotations.HttpRule) (*httpBinding, error) {
+
+	b := &httpBinding{
+		body:         rule.GetBody(),
+		responseBody: rule.GetResponseBody(),
+		rpc:          md,
+		fullMethod:   fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()),
+	}
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		b.method, b.path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		b.method, b.path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		b.method, b.path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		b.method, b.path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		b.method, b.path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		b.method, b.path = strings.ToUpper(p.Custom.GetKind()), p.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("http rule has no pattern")
+	}
+
+	var err error
+	if b.input, err = protoregistry.GlobalTypes.FindMessageByName(md.Input().FullName()); err != nil {
+		return nil, err
+	}
+	if b.output, err = protoregistry.GlobalTypes.FindMessageByName(md.Output().FullName()); err != nil {
+		return nil, err
+	}
+
+	// only simple variables are supported, not {name=pattern} or verbs
+	if strings.ContainsAny(templateVar.ReplaceAllString(b.path, ""), ":*{}") {
+		return nil, fmt.Errorf("unsupported path template %q", b.path)
+	}
+	for _, m := range templateVar.FindAllStringSubmatch(b.path, -1) {
+		if _, err := fieldPath(b.input.Descriptor(), m[1]); err != nil {
+			return nil, fmt.Errorf("path template %q: %v", b.path, err)
+		}
+		b.pathFields = append(b.pathFields, m[1])
+	}
+
+	switch {
+	case md.IsStreamingClient() && b.body != "*":
+		return nil, fmt.Errorf("client streams must bind the body to *")
+	case b.body != "" && b.body != "*":
+		if fd := b.input.Descriptor().Fields().ByName(protoreflect.Name(b.body)); fd == nil || fd.Kind() != protoreflect.MessageKind || fd.IsList() {
+			return nil, fmt.Errorf("body %q is not a message field", b.body)
+		}
+	}
+	if b.responseBody != "" {
+		if fd := b.output.Descriptor().Fields().ByName(protoreflect.Name(b.responseBody)); fd == nil || fd.Kind() != protoreflect.MessageKind || fd.IsList() {
+			return nil, fmt.Errorf("response_body %q is not a message field", b.responseBody)
+		}
+	}
+	return b, nil
+}
+
+// name is the name of the binding's route, e.g. v1getxref
+func (b *httpBinding) name() string {
+	return "v1" + strings.ToLower(string(b.rpc.Name()))
+}
+
+// ginPath is the binding's path in gin's syntax, e.g. /v1/xrefs/:lastfour
+func (b *httpBinding) ginPath() string {
+	return templateVar.ReplaceAllString(b.path, ":$1")
+}
+
+// watch reports whether the binding's rpc is a watch, a server stream
+// running until cancelled
+func (b *httpBinding) watch() bool {
+	return b.rpc.IsStreamingServer() && !b.rpc.IsStreamingClient() && strings.HasPrefix(string(b.rpc.Name()), "Watch")
+}
+
+// transcode registers the routes of bindings. Unary rpcs default to the
+// unary deadline and streams to the streaming one, except watches which
+// have none and end when the gateway shuts down.
+func transcode(r gin.IRoutes, bindings []*httpBinding, unary, streaming time.Duration) {
+	for _, b := range bindings {
+		switch {
+		case b.watch():
+			r.Handle(b.method, b.ginPath(), deadline(b.name(), 0), until(draining), b.handle)
+		case b.rpc.IsStreamingClient() || b.rpc.IsStreamingServer():
+			r.Handle(b.method, b.ginPath(), deadline(b.name(), streaming), b.handle)
+		default:
+			r.Handle(b.method, b.ginPath(), deadline(b.name(), unary), b.handle)
+		}
+	}
+}
+
+// handle calls the binding's rpc with the request built from the HTTP
+// request, mapping messages with protojson. Server streams are written as
+// ndjson unless another format is asked for.
+func (b *httpBinding) handle(c *gin.Context) {
+	conn := c.MustGet("grpcsvr").(*grpc.ClientConn)
+	ctx := c.Request.Context()
+
+	var ins []proto.Message
+	if b.rpc.IsStreamingClient() {
+		var err error
+		ins, err = readMessages(c.Request.Body, b.input)
+		if err != nil {
+			badRequest(c, err.Error())
+			return
+		}
+		for _, in := range ins {
+			if !b.bindPath(c, in) {
+				return
+			}
+		}
+	} else {
+		in, ok := b.request(c)
+		if !ok {
+			return
+		}
+		ins = []proto.Message{in}
+	}
+
+	format := formatJSON
+	if b.rpc.IsStreamingServer() {
+		var ok bool
+		if format, ok = streamFormatOf(c, formatNDJSON); !ok {
+			return
+		}
+	}
+
+	/*******
+	* gRPC *
+	********/
+
+	if !b.rpc.IsStreamingClient() && !b.rpc.IsStreamingServer() {
+		out := b.output.New().Interface()
+		if err := conn.Invoke(ctx, b.fullMethod, ins[0], out); err != nil {
+			renderError(c, err)
+			return
+		}
+		renderProto(c, b.response(out))
+		return
+	}
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    string(b.rpc.Name()),
+		ServerStreams: b.rpc.IsStreamingServer(),
+		ClientStreams: b.rpc.IsStreamingClient(),
+	}, b.fullMethod)
+	if err != nil {
+		renderError(c, err)
+		return
+	}
+	go func() {
+		for _, in := range ins {
+			if err := stream.SendMsg(in); err != nil {
+				return // the server's error is returned by RecvMsg
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	recv := func() (proto.Message, error) {
+		out := b.output.New().Interface()
+		if err := stream.RecvMsg(out); err != nil {
+			return nil, err
+		}
+		return b.response(out), nil
+	}
+	if !b.rpc.IsStreamingServer() {
+		out, err := recv()
+		if err != nil {
+			renderError(c, err)
+			return
+		}
+		renderProto(c, out)
+		return
+	}
+	forwardStream(c, format, string(b.output.Descriptor().Name()), recv, nil)
+}
+
+// request builds the request message from the body, query params and path
+// of the HTTP request, later ones overriding earlier ones
+func (b *httpBinding) request(c *gin.Context) (proto.Message, bool) {
+
+	in := b.input.New().Interface()
+	if b.body != "" {
+		target := in
+		if b.body != "*" {
+			fd := b.input.Descriptor().Fields().ByName(protoreflect.Name(b.body))
+			target = in.ProtoReflect().Mutable(fd).Message().Interface()
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			badRequest(c, fmt.Sprintf("unable to read body: %v", err))
+			return nil, false
+		}
+		if err := protojson.Unmarshal(body, target); err != nil {
+			badRequest(c, fmt.Sprintf("invalid body: %v", err))
+			return nil, false
+		}
+	}
+
+	if b.body != "*" {
+		for name, values := range c.Request.URL.Query() {
+			// format picks how server streams are written
+			if name == "format" && b.rpc.IsStreamingServer() {
+				continue
+			}
+			if err := setField(in.ProtoReflect(), name, values...); err != nil {
+				badRequest(c, fmt.Sprintf("query param %q: %v", name, err))
+				return nil, false
+			}
+		}
+	}
+
+	if !b.bindPath(c, in) {
+		return nil, false
+	}
+	return in, true
+}
+
+// bindPath sets the path fields of in from the route's params
+func (b *httpBinding) bindPath(c *gin.Context, in proto.Message) bool {
+	for _, name := range b.pathFields {
+		if err := setField(in.ProtoReflect(), name, c.Param(name)); err != nil {
+			badRequest(c, fmt.Sprintf("path param %q: %v", name, err))
+			return false
+		}
+	}
+	return true
+}
+
+// response returns the part of out written as the response body
+func (b *httpBinding) response(out proto.Message) proto.Message {
+	if b.responseBody == "" {
+		return out
+	}
+	fd := b.output.Descriptor().Fields().ByName(protoreflect.Name(b.responseBody))
+	return out.ProtoReflect().Get(fd).Message().Interface()
+}
+
+// readMessages reads the messages of a client stream from a body holding
+// either a JSON array of them or a sequence of them, such as ndjson
+func readMessages(body io.Reader, mt protoreflect.MessageType) ([]proto.Message, error) {
+
+	r := bufio.NewReader(body)
+	array := false
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		if !unicode.IsSpace(rune(c)) {
+			r.UnreadByte()
+			array = c == '['
+			break
+		}
+	}
+
+	dec := json.NewDecoder(r)
+	if array {
+		dec.Token()
+	}
+
+	var ms []proto.Message
+	for !array || dec.More() {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if err == io.EOF && !array {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid body: %v", err)
+		}
+		m := mt.New().Interface()
+		if err := protojson.Unmarshal(raw, m); err != nil {
+			return nil, fmt.Errorf("invalid message %d: %v", len(ms)+1, err)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// fieldPath resolves a dotted path of field names, proto or JSON, to the
+// fields along it
+func fieldPath(md protoreflect.MessageDescriptor, path string) ([]protoreflect.FieldDescriptor, error) {
+
+	var fds []protoreflect.FieldDescriptor
+	for i, name := range strings.Split(path, ".") {
+		if i > 0 {
+			prev := fds[i-1]
+			if prev.Kind() != protoreflect.MessageKind || prev.IsList() || prev.IsMap() {
+				return nil, fmt.Errorf("%s is not a message field", prev.Name())
+			}
+			md = prev.Message()
+		}
+		fd := md.Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		fds = append(fds, fd)
+	}
+	if last := fds[len(fds)-1]; last.IsMap() {
+		return nil, fmt.Errorf("map field %s can't be set from a string", last.Name())
+	}
+	return fds, nil
+}
+
+// setField sets the field at path of m from string values, appending them
+// to repeated fields
+func setField(m protoreflect.Message, path string, values ...string) error {
+
+	fds, err := fieldPath(m.Descriptor(), path)
+	if err != nil {
+		return err
+	}
+	for _, fd := range fds[:len(fds)-1] {
+		m = m.Mutable(fd).Message()
+	}
+
+	fd := fds[len(fds)-1]
+	if !fd.IsList() && len(values) > 1 {
+		return fmt.Errorf("field %s is not repeated", fd.Name())
+	}
+	for _, s := range values {
+		var elem protoreflect.Value
+		switch {
+		case fd.Kind() == protoreflect.MessageKind && fd.IsList():
+			elem = m.Mutable(fd).List().NewElement()
+		case fd.Kind() == protoreflect.MessageKind:
+			elem = m.NewField(fd)
+		}
+		v, err := parseValue(fd, elem, s)
+		if err != nil {
+			return err
+		}
+		if fd.IsList() {
+			m.Mutable(fd).List().Append(v)
+		} else {
+			m.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+// parseValue parses a single field value from its string form. Messages,
+// such as Timestamp and Duration, are parsed from their JSON string into
+// elem.
+func parseValue(fd protoreflect.FieldDescriptor, elem protoreflect.Value, s string) (protoreflect.Value, error) {
+
+	invalid := fmt.Errorf("invalid %s value %q", fd.Kind(), s)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			if b, err = base64.URLEncoding.DecodeString(s); err != nil {
+				return elem, invalid
+			}
+		}
+		return protoreflect.ValueOfBytes(b), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(strings.ToUpper(s))); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfInt32(int32(n)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfInt64(n), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfUint32(uint32(n)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfUint64(n), nil
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return elem, invalid
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	case protoreflect.MessageKind:
+		b, _ := json.Marshal(s)
+		if err := protojson.Unmarshal(b, elem.Message().Interface()); err != nil {
+			return elem, invalid
+		}
+		return elem, nil
+	}
+	return elem, fmt.Errorf("unsupported field %s", fd.Name())
+}
+
+// apiRoute documents the binding's route
+func (b *httpBinding) apiRoute() apiRoute {
+
+	r := apiRoute{
+		method:  strings.ToLower(b.method),
+		path:    b.path,
+		summary: string(b.rpc.Name()) + " rpc",
+	}
+
+	bound := map[string]bool{b.body: true}
+	for _, name := range b.pathFields {
+		r.params = append(r.params, pathParam(name, ""))
+		bound[name] = true
+	}
+
+	// top level scalar fields can be set by query params
+	if b.body != "*" {
+		fields := b.input.Descriptor().Fields()
+		for i := 0; i < fields.Len(); i++ {
+			fd := fields.Get(i)
+			if bound[string(fd.Name())] || fd.Kind() == protoreflect.MessageKind || fd.IsMap() {
+				continue
+			}
+			s := fieldSchema(fd, nil)
+			if fd.IsList() {
+				s = arrayOf(s)
+			}
+			r.params = append(r.params, queryParam(string(fd.Name()), "", s))
+		}
+	}
+
+	switch {
+	case b.body == "*" && b.rpc.IsStreamingClient():
+		in := ref(string(b.input.Descriptor().FullName()))
+		r.body = map[string]schema{"application/json": arrayOf(in), ndjsonContentType: in}
+	case b.body == "*":
+		r.body = jsonContent(ref(string(b.input.Descriptor().FullName())))
+	case b.body != "":
+		fd := b.input.Descriptor().Fields().ByName(protoreflect.Name(b.body))
+		r.body = jsonContent(ref(string(fd.Message().FullName())))
+	}
+
+	out := ref(string(b.output.Descriptor().FullName()))
+	if b.responseBody != "" {
+		fd := b.output.Descriptor().Fields().ByName(protoreflect.Name(b.responseBody))
+		out = ref(string(fd.Message().FullName()))
+	}
+	if b.rpc.IsStreamingServer() {
+		r.params = append(r.params, formatParam)
+		r.content = streamContent(out, true)
+	} else {
+		r.content = jsonContent(out)
+	}
+	return r
+}
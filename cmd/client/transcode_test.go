@@ -0,0 +1,191 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestHTTPBindings(t *testing.T) {
+	bindings, err := httpBindings()
+	if err != nil {
+		t.Fatalf("httpBindings: %v", err)
+	}
+	if len(bindings) == 0 {
+		t.Fatal("no bindings")
+	}
+}
+
+func TestNewHTTPBinding(t *testing.T) {
+
+	sd := xref.File_xref_xref_proto.Services().ByName("XrefService")
+	get := func(path string) *annotations.HttpRule {
+		return &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: path}}
+	}
+	post := func(path, body string) *annotations.HttpRule {
+		return &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: path}, Body: body}
+	}
+
+	tests := []struct {
+		name    string
+		method  protoreflect.Name
+		rule    *annotations.HttpRule
+		verb    string
+		ginPath string
+		fields  []string
+		err     string
+	}{
+		{name: "path variable", method: "GetXref", rule: post("/v1/xrefs/{lastfour}", ""), verb: "POST", ginPath: "/v1/xrefs/:lastfour", fields: []string{"lastfour"}},
+		{name: "no variables", method: "GetMagicNumbers", rule: get("/v1/magicnumbers"), verb: "GET", ginPath: "/v1/magicnumbers"},
+		{name: "json name", method: "GetMagicNumbers", rule: get("/v1/magicnumbers/{pageToken}"), verb: "GET", ginPath: "/v1/magicnumbers/:pageToken", fields: []string{"pageToken"}},
+		{name: "custom", method: "GetXref", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: "head", Path: "/v1/xrefs/{lastfour}"}}}, verb: "HEAD", ginPath: "/v1/xrefs/:lastfour", fields: []string{"lastfour"}},
+		{name: "response body", method: "GetXref", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/xrefs/{lastfour}"}, ResponseBody: "token"}, verb: "GET", ginPath: "/v1/xrefs/:lastfour", fields: []string{"lastfour"}},
+		{name: "client stream", method: "AddXrefs", rule: post("/v1/xrefs", "*"), verb: "POST", ginPath: "/v1/xrefs"},
+		{name: "no pattern", method: "GetXref", rule: &annotations.HttpRule{}, err: "no pattern"},
+		{name: "pattern variable", method: "GetXref", rule: get("/v1/xrefs/{lastfour=*}"), err: "unsupported path template"},
+		{name: "verb", method: "GetXref", rule: get("/v1/xrefs/{lastfour}:rotate"), err: "unsupported path template"},
+		{name: "wildcard", method: "GetXref", rule: get("/v1/*"), err: "unsupported path template"},
+		{name: "unclosed variable", method: "GetXref", rule: get("/v1/xrefs/{lastfour"), err: "unsupported path template"},
+		{name: "unknown variable", method: "GetXref", rule: get("/v1/xrefs/{nope}"), err: `unknown field "nope"`},
+		{name: "client stream without body", method: "AddXrefs", rule: post("/v1/xrefs", ""), err: "client streams must bind the body to *"},
+		{name: "scalar body", method: "GetXref", rule: post("/v1/xrefs", "lastfour"), err: `body "lastfour" is not a message field`},
+		{name: "scalar response body", method: "GetXref", rule: &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/v1/xrefs/{lastfour}"}, ResponseBody: "lastfour"}, err: `response_body "lastfour" is not a message field`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := newHTTPBinding(sd, sd.Methods().ByName(tt.method), tt.rule)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newHTTPBinding: %v", err)
+			}
+			if b.method != tt.verb {
+				t.Errorf("method = %s, want %s", b.method, tt.verb)
+			}
+			if p := b.ginPath(); p != tt.ginPath {
+				t.Errorf("gin path = %s, want %s", p, tt.ginPath)
+			}
+			if strings.Join(b.pathFields, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("path fields = %v, want %v", b.pathFields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestSetField(t *testing.T) {
+
+	occurred := time.Date(2022, 6, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		msg    proto.Message
+		path   string
+		values []string
+		want   proto.Message
+		err    string
+	}{
+		{name: "string", msg: &xref.XrefRequest{}, path: "lastfour", values: []string{"1234"}, want: &xref.XrefRequest{Lastfour: "1234"}},
+		{name: "enum name", msg: &xref.Status{}, path: "status", values: []string{"UNAVAILABLE"}, want: &xref.Status{Status: xref.Status_UNAVAILABLE}},
+		{name: "enum lower case", msg: &xref.Status{}, path: "status", values: []string{"unavailable"}, want: &xref.Status{Status: xref.Status_UNAVAILABLE}},
+		{name: "enum number", msg: &xref.Status{}, path: "status", values: []string{"1"}, want: &xref.Status{Status: xref.Status_STATUS(1)}},
+		{name: "unknown enum", msg: &xref.Status{}, path: "status", values: []string{"retired"}, err: "invalid enum value"},
+		{name: "uint32", msg: &xref.Status{}, path: "page_size", values: []string{"50"}, want: &xref.Status{PageSize: 50}},
+		{name: "uint32 json name", msg: &xref.Status{}, path: "pageSize", values: []string{"50"}, want: &xref.Status{PageSize: 50}},
+		{name: "negative uint32", msg: &xref.Status{}, path: "page_size", values: []string{"-1"}, err: "invalid uint32 value"},
+		{name: "bool", msg: &xref.Status{}, path: "include_details", values: []string{"true"}, want: &xref.Status{IncludeDetails: true}},
+		{name: "bad bool", msg: &xref.Status{}, path: "include_details", values: []string{"yes"}, err: "invalid bool value"},
+		{name: "nested", msg: &xref.XrefEvent{}, path: "token.value", values: []string{"1000001234"}, want: &xref.XrefEvent{Token: &xref.XREF{Value: "1000001234"}}},
+		{name: "through scalar", msg: &xref.XrefRequest{}, path: "lastfour.value", values: []string{"x"}, err: "lastfour is not a message field"},
+		{name: "unknown field", msg: &xref.XrefRequest{}, path: "nope", values: []string{"x"}, err: `unknown field "nope"`},
+		{name: "repeated", msg: &fieldmaskpb.FieldMask{}, path: "paths", values: []string{"a", "b.c"}, want: &fieldmaskpb.FieldMask{Paths: []string{"a", "b.c"}}},
+		{name: "not repeated", msg: &xref.XrefRequest{}, path: "lastfour", values: []string{"1234", "5678"}, err: "field lastfour is not repeated"},
+		{name: "map", msg: &structpb.Struct{}, path: "fields", values: []string{"x"}, err: "map field fields"},
+		{name: "timestamp", msg: &xref.XrefEvent{}, path: "occurred_at", values: []string{"2022-06-01T12:30:00Z"}, want: &xref.XrefEvent{OccurredAt: timestamppb.New(occurred)}},
+		{name: "bad timestamp", msg: &xref.XrefEvent{}, path: "occurredAt", values: []string{"yesterday"}, err: "invalid message value"},
+		{name: "duration", msg: &xref.PoolStats{}, path: "estimated_time_to_exhaustion", values: []string{"1.5s"}, want: &xref.PoolStats{EstimatedTimeToExhaustion: durationpb.New(1500 * time.Millisecond)}},
+		{name: "wrapper", msg: &wrapperspb.Int64Value{}, path: "value", values: []string{"-7"}, want: &wrapperspb.Int64Value{Value: -7}},
+		{name: "bytes", msg: &wrapperspb.BytesValue{}, path: "value", values: []string{"aGk="}, want: &wrapperspb.BytesValue{Value: []byte("hi")}},
+		{name: "url bytes", msg: &wrapperspb.BytesValue{}, path: "value", values: []string{"-_8="}, want: &wrapperspb.BytesValue{Value: []byte{0xfb, 0xff}}},
+		{name: "double", msg: &wrapperspb.DoubleValue{}, path: "value", values: []string{"2.5"}, want: &wrapperspb.DoubleValue{Value: 2.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := setField(tt.msg.ProtoReflect(), tt.path, tt.values...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setField: %v", err)
+			}
+			if !proto.Equal(tt.msg, tt.want) {
+				t.Errorf("got %v, want %v", tt.msg, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadMessages(t *testing.T) {
+
+	req := func(lastFour string) proto.Message {
+		return &xref.XrefRequest{Lastfour: lastFour}
+	}
+	mt := (&xref.XrefRequest{}).ProtoReflect().Type()
+
+	tests := []struct {
+		name string
+		body string
+		want []proto.Message
+		err  string
+	}{
+		{name: "array", body: `[{"lastfour":"1111"},{"lastfour":"2222"}]`, want: []proto.Message{req("1111"), req("2222")}},
+		{name: "array with whitespace", body: "\n  [ {\"lastfour\":\"1111\"} ,\n {\"lastfour\":\"2222\"} ]\n", want: []proto.Message{req("1111"), req("2222")}},
+		{name: "empty array", body: `[]`},
+		{name: "ndjson", body: "{\"lastfour\":\"1111\"}\n{\"lastfour\":\"2222\"}\n", want: []proto.Message{req("1111"), req("2222")}},
+		{name: "concatenated", body: `{"lastfour":"1111"}{"lastfour":"2222"}`, want: []proto.Message{req("1111"), req("2222")}},
+		{name: "empty", body: ""},
+		{name: "blank", body: " \n\t"},
+		{name: "unclosed array", body: `[{"lastfour":"1111"}`, err: "invalid body"},
+		{name: "bad json", body: "{\"lastfour\":\"1111\"}\n{nope\n", err: "invalid body"},
+		{name: "unknown field", body: `[{"lastfour":"1111"},{"last4":"2222"}]`, err: "invalid message 2"},
+		{name: "wrong type", body: `{"lastfour":1111}`, err: "invalid message 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms, err := readMessages(strings.NewReader(tt.body), mt)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readMessages: %v", err)
+			}
+			if len(ms) != len(tt.want) {
+				t.Fatalf("%d messages, want %d", len(ms), len(tt.want))
+			}
+			for i := range ms {
+				if !proto.Equal(ms[i], tt.want[i]) {
+					t.Errorf("message %d = %v, want %v", i, ms[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
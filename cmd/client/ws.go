@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// maxFrameSize is the largest websocket frame accepted from clients
+const maxFrameSize = 1 << 20
+
+var wsOrigins = flag.String("ws-origins", "", "comma separated origins, such as https://app.example.com, whose pages may open websockets besides the gateway's own")
+
+// checkWSOrigin refuses websockets opened by pages of other sites, which
+// would ride on the browser's credentials. Clients other than browsers send
+// no Origin and are let through.
+func checkWSOrigin(config *websocket.Config, req *http.Request) error {
+	origin, err := websocket.Origin(config, req)
+	if err != nil {
+		return err
+	}
+	config.Origin = origin
+	if origin == nil || strings.EqualFold(origin.Host, req.Host) {
+		return nil
+	}
+	for _, allowed := range strings.Split(*wsOrigins, ",") {
+		allowed = strings.TrimSuffix(strings.TrimSpace(allowed), "/")
+		if allowed != "" && strings.EqualFold(allowed, origin.Scheme+"://"+origin.Host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("origin %s not allowed", origin)
+}
+
+// getXrefsWS bridges a websocket to a GetXrefs stream. Each text frame
+// sent by the client is a key, an array of keys or an XrefRequest object;
+// a null frame ends the input. Every XrefResponse is sent back as a text
+// frame as it arrives, and the socket is closed once the stream ends, after
+// an error frame if it failed.
+//
+// Keys are only read from the socket as fast as the server accepts them and
+// responses only received as fast as the client reads them, so a slow side
+// holds back the other through gRPC flow control.
+func getXrefsWS(c *gin.Context) {
+	xsvc := c.MustGet("xsvc").(xref.XrefServiceClient)
+
+	s := websocket.Server{Handshake: checkWSOrigin, Handler: func(ws *websocket.Conn) {
+		ws.MaxPayloadBytes = maxFrameSize
+
+		ctx, cancel := context.WithCancel(c.Request.Context())
+		defer cancel()
+
+		/*******
+		* gRPC *
+		********/
+
+		stream, err := xsvc.GetXrefs(ctx)
+		if err != nil {
+			sendWSError(ws, err)
+			return
+		}
+
+		// frames -> requests
+		go func() {
+			defer stream.CloseSend()
+			for {
+				var frame json.RawMessage
+				if err := websocket.JSON.Receive(ws, &frame); err != nil {
+					if !errors.Is(err, io.EOF) {
+						sendWSError(ws, status.Errorf(codes.InvalidArgument, "invalid frame: %v", err))
+					}
+					// the client is gone or sent garbage
+					cancel()
+					return
+				}
+
+				reqs, end, err := parseFrame(frame)
+				if err != nil {
+					sendWSError(ws, err)
+					cancel()
+					return
+				}
+				for _, req := range reqs {
+					if err := stream.Send(req); err != nil {
+						return // the server's error is returned by Recv
+					}
+				}
+				if end {
+					return
+				}
+			}
+		}()
+
+		// responses -> frames
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				switch {
+				case stopping.Err() != nil:
+					sendWSError(ws, status.Error(codes.Unavailable, "gateway shutting down"))
+				case ctx.Err() == nil:
+					sendWSError(ws, err)
+				}
+				return
+			}
+
+			b, err := marshaler.Marshal(res)
+			if err != nil {
+				sendWSError(ws, err)
+				return
+			}
+			if err := websocket.Message.Send(ws, string(b)); err != nil {
+				return
+			}
+		}
+	}}
+	s.ServeHTTP(c.Writer, c.Request)
+}
+
+// parseFrame returns the requests of a client frame and whether it ends the
+// input
+func parseFrame(frame json.RawMessage) ([]*xref.XrefRequest, bool, error) {
+
+	if string(frame) == "null" {
+		return nil, true, nil
+	}
+
+	var key string
+	if err := json.Unmarshal(frame, &key); err == nil {
+		return []*xref.XrefRequest{{Lastfour: key}}, false, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal(frame, &keys); err == nil {
+		reqs := make([]*xref.XrefRequest, len(keys))
+		for i, key := range keys {
+			reqs[i] = &xref.XrefRequest{Lastfour: key}
+		}
+		return reqs, false, nil
+	}
+
+	req := &xref.XrefRequest{}
+	if err := protojson.Unmarshal(frame, req); err != nil {
+		return nil, false, status.Error(codes.InvalidArgument, "frame must be a key, an array of keys or an XrefRequest")
+	}
+	return []*xref.XrefRequest{req}, false, nil
+}
+
+// sendWSError sends the error body of err as a final text frame
+func sendWSError(ws *websocket.Conn, err error) {
+	_, body := errorBodyOf(err)
+	websocket.JSON.Send(ws, body)
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"gopkg.in/yaml.v2"
+)
+
+// ENV_PREFIX prefixes the environment variables overriding the config, a
+// setting's variable is its yaml path in upper case, e.g. XREF_STORE_ADDRS
+const ENV_PREFIX string = "XREF_"
+
+// Config is the server's configuration. It is read from the yaml config
+// file, then overridden by environment variables, then by flags set on the
+// command line.
+type Config struct {
+	// Listen is the address the grpc server listens on
+	Listen     string         `yaml:"listen"`
+	Store      StoreConfig    `yaml:"store"`
+	Init       InitConfig     `yaml:"init"`
+	TLS        TLSConfig      `yaml:"tls"`
+	Auth       AuthConfig     `yaml:"auth"`
+	Limits     LimitsConfig   `yaml:"limits"`
+	Log        LogConfig      `yaml:"log"`
+	Pool       PoolConfig     `yaml:"pool"`
+	Health     HealthConfig   `yaml:"health"`
+	Shutdown   ShutdownConfig `yaml:"shutdown"`
+	Reflection bool           `yaml:"reflection"`
+}
+
+// StoreConfig configures the redis client
+type StoreConfig struct {
+	// Mode is standalone, sentinel or cluster
+	Mode string `yaml:"mode"`
+	// Addrs are the redis server, the sentinels or the cluster seed nodes
+	Addrs []string `yaml:"addrs"`
+	// MasterName is the name of the master monitored by the sentinels
+	MasterName       string        `yaml:"master_name"`
+	Username         string        `yaml:"username"`
+	Password         string        `yaml:"password"`
+	SentinelUsername string        `yaml:"sentinel_username"`
+	SentinelPassword string        `yaml:"sentinel_password"`
+	DB               int           `yaml:"db"`
+	PoolSize         int           `yaml:"pool_size"`
+	DialTimeout      time.Duration `yaml:"dial_timeout"`
+	// KeyTag is the hash tag shared by every key, keeping them in one
+	// cluster slot. It defaults to xref in cluster mode.
+	KeyTag string         `yaml:"key_tag"`
+	TLS    StoreTLSConfig `yaml:"tls"`
+}
+
+// StoreTLSConfig configures TLS to redis
+type StoreTLSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CA verifies the server, the system roots are used when unset
+	CA string `yaml:"ca"`
+	// Cert and Key are the client certificate, for servers verifying
+	// clients
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// store modes
+const (
+	STORE_STANDALONE string = "standalone"
+	STORE_SENTINEL   string = "sentinel"
+	STORE_CLUSTER    string = "cluster"
+)
+
+// InitConfig configures how the store is prepared at startup
+type InitConfig struct {
+	// Mode is reset, if-empty or skip
+	Mode string `yaml:"mode"`
+	// Data is the file of magic numbers, one per line
+	Data string `yaml:"data"`
+}
+
+// TLSConfig configures transport security, the server is plaintext
+// without a certificate. The files are reloaded when they change.
+type TLSConfig struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	// ClientCA verifies client certificates
+	ClientCA string `yaml:"client_ca"`
+	// ClientAuth is none, request to verify client certificates when sent
+	// or require to refuse clients without one
+	ClientAuth string `yaml:"client_auth"`
+}
+
+// client auth modes
+const (
+	CLIENT_AUTH_NONE    string = "none"
+	CLIENT_AUTH_REQUEST string = "request"
+	CLIENT_AUTH_REQUIRE string = "require"
+)
+
+// AuthConfig configures how callers authenticate, calls aren't
+// authenticated when neither jwks nor api_keys is set. The files are
+// reloaded when they change.
+type AuthConfig struct {
+	// JWKS is the file of keys verifying bearer tokens
+	JWKS string `yaml:"jwks"`
+	// Issuer and Audience, when set, must match the iss and aud of tokens
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// APIKeys is a file of name:key lines
+	APIKeys string `yaml:"api_keys"`
+}
+
+// LimitsConfig bounds what a client can ask of the server, 0 keeps the
+// grpc default
+type LimitsConfig struct {
+	MaxRecvMsgBytes      int           `yaml:"max_recv_msg_bytes"`
+	MaxSendMsgBytes      int           `yaml:"max_send_msg_bytes"`
+	MaxConcurrentStreams uint32        `yaml:"max_concurrent_streams"`
+	MaxConnectionAge     time.Duration `yaml:"max_connection_age"`
+}
+
+// LogConfig configures logging
+type LogConfig struct {
+	// Level is info, or debug to log every rpc
+	Level string `yaml:"level"`
+	// File is appended to instead of writing to stderr
+	File string `yaml:"file"`
+}
+
+// PoolConfig configures pool level monitoring
+type PoolConfig struct {
+	Interval          time.Duration `yaml:"interval"`
+	LowWatermark      uint64        `yaml:"low_watermark"`
+	CriticalWatermark uint64        `yaml:"critical_watermark"`
+	AlertWebhook      string        `yaml:"alert_webhook"`
+}
+
+// HealthConfig configures the checks behind the health service
+type HealthConfig struct {
+	Interval       time.Duration `yaml:"interval"`
+	MaxPingLatency time.Duration `yaml:"max_ping_latency"`
+}
+
+// ShutdownConfig configures how the server stops on SIGINT or SIGTERM
+type ShutdownConfig struct {
+	// DrainTimeout is how long rpcs in flight have to finish before they
+	// are cancelled, 0 cancels them right away
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+// log levels
+const (
+	LOG_INFO  string = "info"
+	LOG_DEBUG string = "debug"
+)
+
+func defaultConfig() *Config {
+	return &Config{
+		Listen: ":50051",
+		Store: StoreConfig{
+			Mode:        STORE_STANDALONE,
+			Addrs:       []string{"localhost:6379"},
+			DialTimeout: 5 * time.Second,
+		},
+		Init: InitConfig{
+			Mode: xref.INIT_RESET,
+			Data: "./data/random",
+		},
+		TLS: TLSConfig{ClientAuth: CLIENT_AUTH_NONE},
+		Log: LogConfig{Level: LOG_INFO},
+		Pool: PoolConfig{
+			Interval: 5 * time.Second,
+		},
+		Health: HealthConfig{
+			Interval:       5 * time.Second,
+			MaxPingLatency: 500 * time.Millisecond,
+		},
+		Shutdown: ShutdownConfig{
+			DrainTimeout: 30 * time.Second,
+		},
+	}
+}
+
+// loadConfig builds the config from its defaults, the config file, the
+// environment and the flags set, in that order, and validates it
+func loadConfig() (*Config, error) {
+
+	cfg := defaultConfig()
+
+	path := *configPath
+	if path == "" {
+		path = os.Getenv(ENV_PREFIX + "CONFIG")
+	}
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(b, cfg); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %v", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), ENV_PREFIX); err != nil {
+		return nil, err
+	}
+	applyFlags(cfg)
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides the fields of v with the environment variables named
+// by prefix and their yaml path
+func applyEnv(v reflect.Value, prefix string) error {
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := prefix + strings.ToUpper(t.Field(i).Tag.Get("yaml"))
+		f := v.Field(i)
+		if f.Kind() == reflect.Struct {
+			if err := applyEnv(f, name+"_"); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		var err error
+		switch {
+		case f.Type() == reflect.TypeOf(time.Duration(0)):
+			var d time.Duration
+			d, err = time.ParseDuration(s)
+			f.SetInt(int64(d))
+		case f.Kind() == reflect.String:
+			f.SetString(s)
+		case f.Type() == reflect.TypeOf([]string(nil)):
+			f.Set(reflect.ValueOf(splitList(s)))
+		case f.Kind() == reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(s)
+			f.SetBool(b)
+		case f.Kind() == reflect.Int:
+			var n int64
+			n, err = strconv.ParseInt(s, 10, 0)
+			f.SetInt(n)
+		case f.Kind() == reflect.Uint32, f.Kind() == reflect.Uint64:
+			var n uint64
+			n, err = strconv.ParseUint(s, 10, f.Type().Bits())
+			f.SetUint(n)
+		default:
+			err = fmt.Errorf("unsupported type %s", f.Type())
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// splitList splits a comma separated list, dropping empty items
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyFlags overrides cfg with the flags set on the command line
+func applyFlags(cfg *Config) {
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Listen = fmt.Sprintf(":%d", *port)
+		case "redis":
+			cfg.Store.Addrs = splitList(*redisAddr)
+		case "init":
+			cfg.Init.Mode = *initMode
+		case "data":
+			cfg.Init.Data = *dataPath
+		case "tls-cert":
+			cfg.TLS.Cert = *tlsCert
+		case "tls-key":
+			cfg.TLS.Key = *tlsKey
+		case "tls-client-ca":
+			cfg.TLS.ClientCA = *tlsClientCA
+		case "tls-client-auth":
+			cfg.TLS.ClientAuth = *tlsClientAuth
+		case "auth-jwks":
+			cfg.Auth.JWKS = *authJWKS
+		case "auth-issuer":
+			cfg.Auth.Issuer = *authIssuer
+		case "auth-audience":
+			cfg.Auth.Audience = *authAudience
+		case "auth-api-keys":
+			cfg.Auth.APIKeys = *authAPIKeys
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "pool-interval":
+			cfg.Pool.Interval = *poolInterval
+		case "low-watermark":
+			cfg.Pool.LowWatermark = *lowWatermark
+		case "critical-watermark":
+			cfg.Pool.CriticalWatermark = *criticalWatermark
+		case "alert-webhook":
+			cfg.Pool.AlertWebhook = *alertWebhook
+		case "health-interval":
+			cfg.Health.Interval = *healthInterval
+		case "max-ping-latency":
+			cfg.Health.MaxPingLatency = *maxPingLatency
+		case "drain-timeout":
+			cfg.Shutdown.DrainTimeout = *drainTimeout
+		case "reflection":
+			cfg.Reflection = *enableReflection
+		}
+	})
+}
+
+// validate reports every invalid setting of cfg
+func (cfg *Config) validate() error {
+
+	var problems []string
+	invalid := func(setting, format string, args ...interface{}) {
+		problems = append(problems, setting+": "+fmt.Sprintf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(cfg.Listen); err != nil {
+		invalid("listen", "%v", err)
+	}
+
+	switch cfg.Store.Mode {
+	case STORE_STANDALONE:
+		if len(cfg.Store.Addrs) != 1 {
+			invalid("store.addrs", "must hold a single address in %s mode", STORE_STANDALONE)
+		}
+	case STORE_SENTINEL:
+		if cfg.Store.MasterName == "" {
+			invalid("store.master_name", "must be set in %s mode", STORE_SENTINEL)
+		}
+	case STORE_CLUSTER:
+		if cfg.Store.DB != 0 {
+			invalid("store.db", "must be 0 in %s mode", STORE_CLUSTER)
+		}
+	default:
+		invalid("store.mode", "must be %s, %s or %s, got %q", STORE_STANDALONE, STORE_SENTINEL, STORE_CLUSTER, cfg.Store.Mode)
+	}
+	if len(cfg.Store.Addrs) == 0 {
+		invalid("store.addrs", "must be set")
+	}
+	if cfg.Store.DB < 0 {
+		invalid("store.db", "must be >= 0")
+	}
+	if strings.ContainsAny(cfg.Store.KeyTag, "{}") {
+		invalid("store.key_tag", "must not contain braces")
+	}
+	storeTLS := cfg.Store.TLS
+	if !storeTLS.Enabled && (storeTLS.CA != "" || storeTLS.Cert != "" || storeTLS.Key != "") {
+		invalid("store.tls", "enabled must be set to use ca, cert or key")
+	}
+	if (storeTLS.Cert == "") != (storeTLS.Key == "") {
+		invalid("store.tls", "cert and key must be set together")
+	}
+	if cfg.Store.PoolSize < 0 {
+		invalid("store.pool_size", "must be >= 0")
+	}
+
+	switch cfg.Init.Mode {
+	case xref.INIT_RESET, xref.INIT_IF_EMPTY:
+		if cfg.Init.Data == "" {
+			invalid("init.data", "must be set for init mode %s", cfg.Init.Mode)
+		} else if _, err := os.Stat(cfg.Init.Data); err != nil {
+			invalid("init.data", "%v", err)
+		}
+	case xref.INIT_SKIP:
+	default:
+		invalid("init.mode", "must be %s, %s or %s, got %q", xref.INIT_RESET, xref.INIT_IF_EMPTY, xref.INIT_SKIP, cfg.Init.Mode)
+	}
+
+	if (cfg.TLS.Cert == "") != (cfg.TLS.Key == "") {
+		invalid("tls", "cert and key must be set together")
+	}
+	switch cfg.TLS.ClientAuth {
+	case CLIENT_AUTH_NONE:
+	case CLIENT_AUTH_REQUEST, CLIENT_AUTH_REQUIRE:
+		if cfg.TLS.ClientCA == "" {
+			invalid("tls.client_ca", "must be set for client auth %s", cfg.TLS.ClientAuth)
+		}
+	default:
+		invalid("tls.client_auth", "must be %s, %s or %s, got %q", CLIENT_AUTH_NONE, CLIENT_AUTH_REQUEST, CLIENT_AUTH_REQUIRE, cfg.TLS.ClientAuth)
+	}
+	if cfg.TLS.ClientCA != "" && cfg.TLS.Cert == "" {
+		invalid("tls.client_ca", "cert and key must be set to verify clients")
+	}
+	if cfg.Auth.JWKS == "" && (cfg.Auth.Issuer != "" || cfg.Auth.Audience != "") {
+		invalid("auth.jwks", "must be set to check the issuer or audience of tokens")
+	}
+	for _, f := range []struct{ setting, path string }{
+		{"tls.cert", cfg.TLS.Cert},
+		{"tls.key", cfg.TLS.Key},
+		{"tls.client_ca", cfg.TLS.ClientCA},
+		{"auth.jwks", cfg.Auth.JWKS},
+		{"auth.api_keys", cfg.Auth.APIKeys},
+		{"store.tls.ca", storeTLS.CA},
+		{"store.tls.cert", storeTLS.Cert},
+		{"store.tls.key", storeTLS.Key},
+	} {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			invalid(f.setting, "%v", err)
+		}
+	}
+
+	if cfg.Limits.MaxRecvMsgBytes < 0 {
+		invalid("limits.max_recv_msg_bytes", "must be >= 0")
+	}
+	if cfg.Limits.MaxSendMsgBytes < 0 {
+		invalid("limits.max_send_msg_bytes", "must be >= 0")
+	}
+	if cfg.Limits.MaxConnectionAge < 0 {
+		invalid("limits.max_connection_age", "must be >= 0")
+	}
+
+	if cfg.Log.Level != LOG_INFO && cfg.Log.Level != LOG_DEBUG {
+		invalid("log.level", "must be %s or %s, got %q", LOG_INFO, LOG_DEBUG, cfg.Log.Level)
+	}
+
+	if cfg.Pool.Interval <= 0 {
+		invalid("pool.interval", "must be > 0")
+	}
+	if cfg.Pool.LowWatermark > 0 && cfg.Pool.CriticalWatermark > cfg.Pool.LowWatermark {
+		invalid("pool.critical_watermark", "must be <= pool.low_watermark")
+	}
+
+	if cfg.Health.Interval <= 0 {
+		invalid("health.interval", "must be > 0")
+	}
+	if cfg.Health.MaxPingLatency < 0 {
+		invalid("health.max_ping_latency", "must be >= 0")
+	}
+
+	if cfg.Shutdown.DrainTimeout < 0 {
+		invalid("shutdown.drain_timeout", "must be >= 0")
+	}
+
+	if problems != nil {
+		return errors.New("invalid config:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
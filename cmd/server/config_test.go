@@ -0,0 +1,259 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
+	"gopkg.in/yaml.v2"
+)
+
+func writeConfig(t *testing.T, yml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadConfigFile runs loadConfig with path as the -config flag
+func loadConfigFile(t *testing.T, path string) (*Config, error) {
+	t.Helper()
+	saved := *configPath
+	*configPath = path
+	defer func() { *configPath = saved }()
+	return loadConfig()
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	// flags stay set once set, so the cases setting none run first
+	t.Run("unknown setting", func(t *testing.T) {
+		_, err := loadConfigFile(t, writeConfig(t, "init:\n  mode: skip\nlistn: \":7000\"\n"))
+		if err == nil || !strings.Contains(err.Error(), "listn") {
+			t.Errorf("err = %v, want unknown field listn", err)
+		}
+	})
+	t.Run("bad env", func(t *testing.T) {
+		t.Setenv("XREF_POOL_INTERVAL", "5")
+		_, err := loadConfigFile(t, writeConfig(t, "init:\n  mode: skip\n"))
+		if err == nil || !strings.Contains(err.Error(), "invalid XREF_POOL_INTERVAL") {
+			t.Errorf("err = %v, want invalid XREF_POOL_INTERVAL", err)
+		}
+	})
+	t.Run("config env", func(t *testing.T) {
+		t.Setenv("XREF_CONFIG", writeConfig(t, "init:\n  mode: skip\nlog:\n  level: debug\n"))
+		cfg, err := loadConfigFile(t, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Log.Level != LOG_DEBUG {
+			t.Errorf("log.level = %q, want the XREF_CONFIG file's debug", cfg.Log.Level)
+		}
+	})
+
+	t.Run("override order", func(t *testing.T) {
+		path := writeConfig(t, `
+listen: ":7000"
+store:
+  addrs: ["file:6379"]
+  pool_size: 20
+init:
+  mode: skip
+log:
+  level: debug
+pool:
+  interval: 2s
+  low_watermark: 100
+`)
+		t.Setenv("XREF_LISTEN", ":7001")
+		t.Setenv("XREF_STORE_ADDRS", "env:6379")
+		t.Setenv("XREF_POOL_LOW_WATERMARK", "50")
+		t.Setenv("XREF_POOL_CRITICAL_WATERMARK", "5")
+		for name, value := range map[string]string{"port": "7002", "low-watermark": "40"} {
+			if err := flag.Set(name, value); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cfg, err := loadConfigFile(t, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			setting   string
+			got, want interface{}
+		}{
+			{"listen from flag over env and file", cfg.Listen, ":7002"},
+			{"store.addrs from env over file", cfg.Store.Addrs, []string{"env:6379"}},
+			{"store.pool_size from file", cfg.Store.PoolSize, 20},
+			{"log.level from file", cfg.Log.Level, LOG_DEBUG},
+			{"pool.interval from file", cfg.Pool.Interval, 2 * time.Second},
+			{"pool.low_watermark from flag", cfg.Pool.LowWatermark, uint64(40)},
+			{"pool.critical_watermark from env", cfg.Pool.CriticalWatermark, uint64(5)},
+			{"store.mode default", cfg.Store.Mode, STORE_STANDALONE},
+			{"health.interval default", cfg.Health.Interval, 5 * time.Second},
+		}
+		for _, tt := range tests {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.setting, tt.got, tt.want)
+			}
+		}
+	})
+}
+
+func TestApplyEnv(t *testing.T) {
+
+	env := map[string]string{
+		"XREF_LISTEN":                         ":6000",
+		"XREF_STORE_MODE":                     "cluster",
+		"XREF_STORE_ADDRS":                    "a:1, b:2,,c:3",
+		"XREF_STORE_MASTER_NAME":              "primary",
+		"XREF_STORE_DB":                       "3",
+		"XREF_STORE_DIAL_TIMEOUT":             "1500ms",
+		"XREF_STORE_TLS_ENABLED":              "true",
+		"XREF_STORE_TLS_SERVER_NAME":          "redis.internal",
+		"XREF_STORE_TLS_INSECURE_SKIP_VERIFY": "1",
+		"XREF_TLS_CLIENT_AUTH":                "require",
+		"XREF_AUTH_API_KEYS":                  "/etc/xref/keys",
+		"XREF_LIMITS_MAX_CONCURRENT_STREAMS":  "100",
+		"XREF_POOL_LOW_WATERMARK":             "1000",
+		"XREF_HEALTH_MAX_PING_LATENCY":        "250ms",
+		"XREF_SHUTDOWN_DRAIN_TIMEOUT":         "0s",
+		"XREF_REFLECTION":                     "true",
+	}
+	for name, value := range env {
+		t.Setenv(name, value)
+	}
+
+	cfg := defaultConfig()
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), ENV_PREFIX); err != nil {
+		t.Fatal(err)
+	}
+
+	want := defaultConfig()
+	want.Listen = ":6000"
+	want.Store.Mode = STORE_CLUSTER
+	want.Store.Addrs = []string{"a:1", "b:2", "c:3"}
+	want.Store.MasterName = "primary"
+	want.Store.DB = 3
+	want.Store.DialTimeout = 1500 * time.Millisecond
+	want.Store.TLS = StoreTLSConfig{Enabled: true, ServerName: "redis.internal", InsecureSkipVerify: true}
+	want.TLS.ClientAuth = CLIENT_AUTH_REQUIRE
+	want.Auth.APIKeys = "/etc/xref/keys"
+	want.Limits.MaxConcurrentStreams = 100
+	want.Pool.LowWatermark = 1000
+	want.Health.MaxPingLatency = 250 * time.Millisecond
+	want.Shutdown.DrainTimeout = 0
+	want.Reflection = true
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("config = %+v\nwant %+v", cfg, want)
+	}
+}
+
+func TestApplyEnvErrors(t *testing.T) {
+
+	tests := []struct {
+		name, value string
+	}{
+		{"XREF_STORE_DB", "zero"},
+		{"XREF_STORE_TLS_ENABLED", "yes please"},
+		{"XREF_POOL_INTERVAL", "5"},
+		{"XREF_POOL_LOW_WATERMARK", "-1"},
+		{"XREF_LIMITS_MAX_CONCURRENT_STREAMS", "5000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			err := applyEnv(reflect.ValueOf(defaultConfig()).Elem(), ENV_PREFIX)
+			if err == nil || !strings.HasPrefix(err.Error(), "invalid "+tt.name+":") {
+				t.Errorf("err = %v, want invalid %s", err, tt.name)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	file := writeConfig(t, "")
+	valid := func() *Config {
+		cfg := defaultConfig()
+		cfg.Init.Mode = xref.INIT_SKIP
+		return cfg
+	}
+	if err := valid().validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		want   string
+	}{
+		{"listen", func(c *Config) { c.Listen = "7000" }, "listen: address 7000: missing port in address"},
+		{"store mode", func(c *Config) { c.Store.Mode = "replica" }, `store.mode: must be standalone, sentinel or cluster, got "replica"`},
+		{"standalone addrs", func(c *Config) { c.Store.Addrs = []string{"a:1", "b:2"} }, "store.addrs: must hold a single address in standalone mode"},
+		{"no addrs", func(c *Config) { c.Store.Mode, c.Store.MasterName, c.Store.Addrs = STORE_SENTINEL, "m", nil }, "store.addrs: must be set"},
+		{"sentinel master", func(c *Config) { c.Store.Mode = STORE_SENTINEL }, "store.master_name: must be set in sentinel mode"},
+		{"cluster db", func(c *Config) { c.Store.Mode, c.Store.DB = STORE_CLUSTER, 1 }, "store.db: must be 0 in cluster mode"},
+		{"key tag", func(c *Config) { c.Store.KeyTag = "{xref}" }, "store.key_tag: must not contain braces"},
+		{"store tls disabled", func(c *Config) { c.Store.TLS.CA = file }, "store.tls: enabled must be set to use ca, cert or key"},
+		{"store tls pair", func(c *Config) { c.Store.TLS.Enabled, c.Store.TLS.Cert = true, file }, "store.tls: cert and key must be set together"},
+		{"init mode", func(c *Config) { c.Init.Mode = "wipe" }, `init.mode: must be reset, if-empty or skip, got "wipe"`},
+		{"init data", func(c *Config) { c.Init.Mode, c.Init.Data = xref.INIT_RESET, "" }, "init.data: must be set for init mode reset"},
+		{"missing init data", func(c *Config) { c.Init.Mode, c.Init.Data = xref.INIT_IF_EMPTY, "/nonexistent/data" }, "init.data: stat /nonexistent/data"},
+		{"tls pair", func(c *Config) { c.TLS.Cert = file }, "tls: cert and key must be set together"},
+		{"client auth", func(c *Config) { c.TLS.ClientAuth = "optional" }, `tls.client_auth: must be none, request or require, got "optional"`},
+		{"client auth ca", func(c *Config) { c.TLS.ClientAuth = CLIENT_AUTH_REQUIRE }, "tls.client_ca: must be set for client auth require"},
+		{"client ca without tls", func(c *Config) { c.TLS.ClientCA = file }, "tls.client_ca: cert and key must be set to verify clients"},
+		{"missing cert", func(c *Config) { c.TLS.Cert, c.TLS.Key = "/nonexistent/cert", file }, "tls.cert: stat /nonexistent/cert"},
+		{"issuer without jwks", func(c *Config) { c.Auth.Issuer = "idp" }, "auth.jwks: must be set to check the issuer or audience of tokens"},
+		{"missing api keys", func(c *Config) { c.Auth.APIKeys = "/nonexistent/keys" }, "auth.api_keys: stat /nonexistent/keys"},
+		{"limits", func(c *Config) { c.Limits.MaxRecvMsgBytes = -1 }, "limits.max_recv_msg_bytes: must be >= 0"},
+		{"log level", func(c *Config) { c.Log.Level = "trace" }, `log.level: must be info or debug, got "trace"`},
+		{"pool interval", func(c *Config) { c.Pool.Interval = 0 }, "pool.interval: must be > 0"},
+		{"watermarks", func(c *Config) { c.Pool.LowWatermark, c.Pool.CriticalWatermark = 10, 20 }, "pool.critical_watermark: must be <= pool.low_watermark"},
+		{"health interval", func(c *Config) { c.Health.Interval = -time.Second }, "health.interval: must be > 0"},
+		{"drain timeout", func(c *Config) { c.Shutdown.DrainTimeout = -time.Second }, "shutdown.drain_timeout: must be >= 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid()
+			tt.modify(cfg)
+			err := cfg.validate()
+			if err == nil {
+				t.Fatalf("validate accepted the config, want %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), "\n  "+tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	t.Run("every problem", func(t *testing.T) {
+		cfg := valid()
+		cfg.Log.Level = "trace"
+		cfg.Pool.Interval = 0
+		err := cfg.validate()
+		want := "invalid config:\n  log.level: must be info or debug, got \"trace\"\n  pool.interval: must be > 0"
+		if err == nil || err.Error() != want {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	})
+}
+
+func TestExampleConfig(t *testing.T) {
+	b, err := os.ReadFile("config.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.UnmarshalStrict(b, defaultConfig()); err != nil {
+		t.Errorf("config.example.yaml: %v", err)
+	}
+}
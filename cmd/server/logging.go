@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// setupLogging points the log at the configured file
+func setupLogging(cfg LogConfig) error {
+	if cfg.File == "" {
+		return nil
+	}
+	f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	log.SetOutput(f)
+	return nil
+}
+
+// logUnary logs every unary rpc with its caller, status and duration
+func logUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	log.Printf("rpc %s %s %s %v", info.FullMethod, caller(ctx), status.Code(err), time.Since(start))
+	return res, err
+}
+
+// logStream logs every streaming rpc with its caller, status and duration
+func logStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("rpc %s %s %s %v", info.FullMethod, caller(ss.Context()), status.Code(err), time.Since(start))
+	return err
+}
+
+// caller names the authenticated caller of ctx, - when there's none
+func caller(ctx context.Context) string {
+	if id, ok := auth.FromContext(ctx); ok {
+		return id.String()
+	}
+	return "-"
+}
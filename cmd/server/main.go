@@ -2,44 +2,206 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
-	"fmt"
 	"log"
 	"net"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/cgeorgiades27/grpc-demo/pkg/auth"
+	"github.com/cgeorgiades27/grpc-demo/pkg/tlsutil"
 	"github.com/cgeorgiades27/grpc-demo/pkg/xref"
-	"github.com/go-redis/redis/v8"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 )
 
 var (
+	configPath = flag.String("config", "", "yaml config file, "+ENV_PREFIX+"CONFIG when unset")
+
+	// flags override the config file and environment when set
 	port      = flag.Int("port", 50051, "The server port")
-	redisAddr = flag.String("redis", "localhost:6379", "redis server address")
+	redisAddr = flag.String("redis", "localhost:6379", "redis server address, or comma separated sentinel or cluster addresses")
+	initMode  = flag.String("init", xref.INIT_RESET, "store init mode: reset, if-empty or skip")
 	dataPath  = flag.String("data", "./data/random", "init data path")
+	tlsCert   = flag.String("tls-cert", "", "server certificate file, serves plaintext when unset")
+	tlsKey    = flag.String("tls-key", "", "server private key file")
+	logLevel  = flag.String("log-level", LOG_INFO, "log level: info, or debug to log every rpc")
+
+	// mutual TLS
+	tlsClientCA   = flag.String("tls-client-ca", "", "CA bundle verifying client certificates")
+	tlsClientAuth = flag.String("tls-client-auth", CLIENT_AUTH_NONE, "client certificates: none, request to verify them when sent, or require")
+
+	// caller authentication
+	authJWKS     = flag.String("auth-jwks", "", "JWK set file verifying bearer tokens")
+	authIssuer   = flag.String("auth-issuer", "", "iss bearer tokens must have")
+	authAudience = flag.String("auth-audience", "", "aud bearer tokens must have")
+	authAPIKeys  = flag.String("auth-api-keys", "", "file of name:key lines, the api keys accepted")
+
+	// pool level monitoring
+	poolInterval      = flag.Duration("pool-interval", 5*time.Second, "pool level check interval")
+	lowWatermark      = flag.Uint64("low-watermark", 0, "available magic numbers at or below which the pool is low, 0 disables")
+	criticalWatermark = flag.Uint64("critical-watermark", 0, "available magic numbers at or below which the pool is critical, 0 disables")
+	alertWebhook      = flag.String("alert-webhook", "", "url receiving pool level changes")
+
+	// health checks
+	healthInterval = flag.Duration("health-interval", 5*time.Second, "health check interval")
+	maxPingLatency = flag.Duration("max-ping-latency", 500*time.Millisecond, "redis ping latency above which the server is not serving, 0 disables")
+
+	// shutdown
+	drainTimeout = flag.Duration("drain-timeout", 30*time.Second, "time rpcs in flight have to finish on SIGINT or SIGTERM before they are cancelled")
+
+	// lets tools such as grpcurl list services and messages without the .proto
+	enableReflection = flag.Bool("reflection", false, "serve grpc server reflection")
 )
 
 func main() {
 
 	flag.Parse()
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "", // no password set
-		DB:       0,  // use default DB
-	})
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := setupLogging(cfg.Log); err != nil {
+		log.Fatalf("failed to open log file: %v", err)
+	}
+
+	rdb, err := newRedisClient(cfg.Store)
+	if err != nil {
+		log.Fatalf("failed to configure redis: %v", err)
+	}
 
-	s := grpc.NewServer()
-	server := xref.NewXrefService(context.Background(), rdb)
-	server.InitData(*dataPath)
+	opts, err := serverOptions(cfg)
+	if err != nil {
+		log.Fatalf("failed to configure server: %v", err)
+	}
+	s := grpc.NewServer(opts...)
+	svcCtx, cancelSvc := context.WithCancel(context.Background())
+	server := xref.NewXrefService(svcCtx, rdb, cfg.Store.keyTag())
+	if err := server.InitStore(cfg.Init.Mode, cfg.Init.Data); err != nil {
+		log.Printf("failed to init data: %v", err)
+	}
+	if err := server.StartPoolMonitor(xref.PoolMonitorConfig{
+		Interval: cfg.Pool.Interval,
+		Low:      cfg.Pool.LowWatermark,
+		Critical: cfg.Pool.CriticalWatermark,
+		Webhook:  cfg.Pool.AlertWebhook,
+	}); err != nil {
+		log.Fatalf("failed to start pool monitor: %v", err)
+	}
+	hs := health.NewServer()
+	if err := server.StartHealthChecks(hs, xref.HealthConfig{
+		Interval:   cfg.Health.Interval,
+		MaxLatency: cfg.Health.MaxPingLatency,
+	}); err != nil {
+		log.Fatalf("failed to start health checks: %v", err)
+	}
 	xref.RegisterXrefServiceServer(s, server)
+	healthpb.RegisterHealthServer(s, hs)
+	if cfg.Reflection {
+		reflection.Register(s)
+	}
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
+	lis, err := net.Listen("tcp", cfg.Listen)
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 	log.Printf("server listening at %v", lis.Addr())
 
-	if err := s.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			log.Fatalf("failed to serve: %v", err)
+		}
+	}()
+	<-ctx.Done()
+	stop() // a second signal kills the server
+
+	// stop taking calls, balancers move to other servers once not serving
+	log.Printf("shutting down, draining rpcs for up to %v", cfg.Shutdown.DrainTimeout)
+	server.Shutdown()
+	drained := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(cfg.Shutdown.DrainTimeout):
+		log.Printf("drain timeout, cancelling rpcs in flight")
+		s.Stop()
+		<-drained
+	}
+
+	cancelSvc()
+	if err := rdb.Close(); err != nil {
+		log.Printf("failed to close redis: %v", err)
+	}
+	log.Printf("server stopped")
+}
+
+// serverOptions applies the TLS, limits, authentication and logging of cfg
+// to the server
+func serverOptions(cfg *Config) ([]grpc.ServerOption, error) {
+
+	var opts []grpc.ServerOption
+	if cfg.TLS.Cert != "" {
+		certs, err := tlsutil.NewReloader(cfg.TLS.Cert, cfg.TLS.Key, cfg.TLS.ClientCA)
+		if err != nil {
+			return nil, err
+		}
+		clientAuth := map[string]tls.ClientAuthType{
+			CLIENT_AUTH_NONE:    tls.NoClientCert,
+			CLIENT_AUTH_REQUEST: tls.VerifyClientCertIfGiven,
+			CLIENT_AUTH_REQUIRE: tls.RequireAndVerifyClientCert,
+		}[cfg.TLS.ClientAuth]
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsutil.ServerConfig(certs, clientAuth))))
+	}
+
+	if n := cfg.Limits.MaxRecvMsgBytes; n > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(n))
+	}
+	if n := cfg.Limits.MaxSendMsgBytes; n > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(n))
+	}
+	if n := cfg.Limits.MaxConcurrentStreams; n > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(n))
+	}
+	if d := cfg.Limits.MaxConnectionAge; d > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{MaxConnectionAge: d}))
+	}
+
+	// callers are authenticated before the rpcs are logged, so the log
+	// names them
+	var unary []grpc.UnaryServerInterceptor
+	var stream []grpc.StreamServerInterceptor
+	if cfg.Auth.JWKS != "" || cfg.Auth.APIKeys != "" {
+		a, err := auth.NewAuthenticator(auth.Config{
+			JWKS:     cfg.Auth.JWKS,
+			Issuer:   cfg.Auth.Issuer,
+			Audience: cfg.Auth.Audience,
+			APIKeys:  cfg.Auth.APIKeys,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if cfg.TLS.Cert == "" {
+			log.Printf("warning: authenticating callers over plaintext, credentials can be read off the wire")
+		}
+		unary = append(unary, a.UnaryServerInterceptor())
+		stream = append(stream, a.StreamServerInterceptor())
+	}
+	if cfg.Log.Level == LOG_DEBUG {
+		unary = append(unary, logUnary)
+		stream = append(stream, logStream)
 	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	return opts, nil
 }
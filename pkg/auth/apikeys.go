@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// apiKeys maps the hashes of API keys to their names, so looking a key up
+// takes no longer for a closer guess
+type apiKeys map[[sha256.Size]byte]string
+
+// loadAPIKeys reads the api keys of path
+func loadAPIKeys(path string) (apiKeys, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseAPIKeys(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return keys, nil
+}
+
+// parseAPIKeys reads name:key lines, skipping blank lines and # comments
+func parseAPIKeys(b []byte) (apiKeys, error) {
+
+	keys := apiKeys{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, key, ok := strings.Cut(line, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("line %d: expected name:key", n)
+		}
+		h := sha256.Sum256([]byte(key))
+		if other, dup := keys[h]; dup {
+			return nil, fmt.Errorf("line %d: key of %s already used by %s", n, name, other)
+		}
+		keys[h] = name
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no api keys")
+	}
+	return keys, nil
+}
+
+func (k apiKeys) lookup(key string) (string, bool) {
+	name, ok := k[sha256.Sum256([]byte(key))]
+	return name, ok
+}
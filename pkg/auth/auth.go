@@ -0,0 +1,180 @@
+// Package auth authenticates grpc callers by bearer JWT, verified against a
+// local JWKS file, or by static API key, and carries the caller's identity
+// in the context of the calls they make.
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/cgeorgiades27/grpc-demo/pkg/reload"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// authentication methods
+const (
+	METHOD_JWT     string = "jwt"
+	METHOD_API_KEY string = "api-key"
+)
+
+// metadata carrying credentials
+const (
+	AUTHORIZATION_HEADER string = "authorization"
+	API_KEY_HEADER       string = "x-api-key"
+)
+
+// Identity is an authenticated caller
+type Identity struct {
+	// Method is jwt or api-key
+	Method string
+	// Subject is the sub claim of a token, or the name of an API key
+	Subject string
+	// Issuer is the iss claim of a token
+	Issuer string
+}
+
+func (id Identity) String() string {
+	return id.Method + ":" + id.Subject
+}
+
+type identityKey struct{}
+
+// NewContext returns ctx carrying the identity of the caller
+func NewContext(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity of the caller, if authenticated
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// Config configures the credentials accepted, either of JWKS and APIKeys
+// may be unset
+type Config struct {
+	// JWKS is the file of keys verifying bearer tokens
+	JWKS string
+	// Issuer and Audience, when set, must match the iss and aud of tokens
+	Issuer   string
+	Audience string
+	// APIKeys is a file of name:key lines
+	APIKeys string
+}
+
+// Authenticator authenticates callers from the metadata of their calls.
+// The JWKS and API key files are reloaded when they change.
+type Authenticator struct {
+	cfg     Config
+	jwks    *reload.Value[[]*jwk]
+	apiKeys *reload.Value[apiKeys]
+}
+
+var errUnauthenticated = status.Error(codes.Unauthenticated, "missing credentials")
+
+// NewAuthenticator loads the files of cfg
+func NewAuthenticator(cfg Config) (*Authenticator, error) {
+
+	if cfg.JWKS == "" && cfg.APIKeys == "" {
+		return nil, errors.New("jwks or api keys must be set")
+	}
+	a := &Authenticator{cfg: cfg}
+	var err error
+	if cfg.JWKS != "" {
+		load := func() ([]*jwk, error) { return loadJWKS(cfg.JWKS) }
+		if a.jwks, err = reload.New("auth jwks", load, cfg.JWKS); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.APIKeys != "" {
+		load := func() (apiKeys, error) { return loadAPIKeys(cfg.APIKeys) }
+		if a.apiKeys, err = reload.New("auth api keys", load, cfg.APIKeys); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Authenticate returns the identity of the caller of ctx, or an
+// Unauthenticated error
+func (a *Authenticator) Authenticate(ctx context.Context) (Identity, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if values := md.Get(AUTHORIZATION_HEADER); len(values) > 0 {
+		scheme, token, _ := strings.Cut(values[0], " ")
+		if !strings.EqualFold(scheme, "bearer") || token == "" {
+			return Identity{}, status.Error(codes.Unauthenticated, "authorization must be a bearer token")
+		}
+		if a.jwks == nil {
+			return Identity{}, status.Error(codes.Unauthenticated, "bearer tokens are not accepted")
+		}
+		id, err := a.verifyToken(strings.TrimSpace(token))
+		if err != nil {
+			return Identity{}, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+		}
+		return id, nil
+	}
+
+	if values := md.Get(API_KEY_HEADER); len(values) > 0 {
+		if a.apiKeys == nil {
+			return Identity{}, status.Error(codes.Unauthenticated, "api keys are not accepted")
+		}
+		name, ok := a.apiKeys.Get().lookup(values[0])
+		if !ok {
+			return Identity{}, status.Error(codes.Unauthenticated, "invalid api key")
+		}
+		return Identity{Method: METHOD_API_KEY, Subject: name}, nil
+	}
+
+	return Identity{}, errUnauthenticated
+}
+
+// exempt reports whether fullMethod is open to unauthenticated callers,
+// health checks are so balancers and probes need no credentials
+func exempt(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// UnaryServerInterceptor authenticates the callers of unary rpcs
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if exempt(info.FullMethod) {
+			return handler(ctx, req)
+		}
+		id, err := a.Authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(NewContext(ctx, id), req)
+	}
+}
+
+// StreamServerInterceptor authenticates the callers of streaming rpcs
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if exempt(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		id, err := a.Authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: NewContext(ss.Context(), id)})
+	}
+}
+
+// identityStream is a server stream whose context carries the caller
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
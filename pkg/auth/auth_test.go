@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+
+	tests := []struct {
+		name string
+		file string
+		keys map[string]string
+		err  bool
+	}{
+		{
+			name: "keys",
+			file: "# ops team\nops:k-ops\n\n  batch : k-batch  \nweird:key:with:colons\n",
+			keys: map[string]string{"k-ops": "ops", "k-batch": "batch", "key:with:colons": "weird"},
+		},
+		{name: "missing colon", file: "ops k-ops\n", err: true},
+		{name: "empty name", file: ":k-ops\n", err: true},
+		{name: "empty key", file: "ops:\n", err: true},
+		{name: "duplicate key", file: "ops:k-same\nbatch:k-same\n", err: true},
+		{name: "only comments", file: "# nothing\n\n", err: true},
+		{name: "empty", file: "", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseAPIKeys([]byte(tt.file))
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseAPIKeys accepted %q", tt.file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAPIKeys: %v", err)
+			}
+			if len(keys) != len(tt.keys) {
+				t.Errorf("%d keys, want %d", len(keys), len(tt.keys))
+			}
+			for key, want := range tt.keys {
+				if name, ok := keys.lookup(key); !ok || name != want {
+					t.Errorf("lookup(%q) = %q %v, want %q", key, name, ok, want)
+				}
+			}
+			for _, key := range []string{"", "k-op", "k-ops ", "K-OPS"} {
+				if name, ok := keys.lookup(key); ok {
+					t.Errorf("lookup(%q) = %q, want no key", key, name)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+
+	a, err := NewAuthenticator(Config{
+		JWKS:    writeFile(t, "jwks.json", testJWKS()),
+		APIKeys: writeFile(t, "keys.txt", "ops:k-ops\n"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := sign(t, map[string]interface{}{"alg": "ES256", "kid": "e1"}, validClaims(), keys.p256)
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want Identity
+		code codes.Code
+	}{
+		{"bearer", metadata.Pairs("authorization", "Bearer "+token), Identity{METHOD_JWT, "alice", "xref-idp"}, codes.OK},
+		{"bearer any case", metadata.Pairs("authorization", "bearer "+token), Identity{METHOD_JWT, "alice", "xref-idp"}, codes.OK},
+		{"api key", metadata.Pairs("x-api-key", "k-ops"), Identity{METHOD_API_KEY, "ops", ""}, codes.OK},
+		{"no credentials", metadata.MD{}, Identity{}, codes.Unauthenticated},
+		{"bad api key", metadata.Pairs("x-api-key", "k-nope"), Identity{}, codes.Unauthenticated},
+		{"basic", metadata.Pairs("authorization", "Basic b3BzOms="), Identity{}, codes.Unauthenticated},
+		{"empty bearer", metadata.Pairs("authorization", "Bearer "), Identity{}, codes.Unauthenticated},
+		{"bad token", metadata.Pairs("authorization", "Bearer "+token+"x"), Identity{}, codes.Unauthenticated},
+		// a bad token isn't rescued by a good api key
+		{"bad token and api key", metadata.Pairs("authorization", "Bearer x", "x-api-key", "k-ops"), Identity{}, codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			id, err := a.Authenticate(ctx)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.code, err)
+			}
+			if id != tt.want {
+				t.Errorf("identity = %+v, want %+v", id, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+
+	a, err := NewAuthenticator(Config{APIKeys: writeFile(t, "keys.txt", "ops:k-ops\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	intercept := a.UnaryServerInterceptor()
+
+	tests := []struct {
+		name   string
+		method string
+		md     metadata.MD
+		caller string
+		code   codes.Code
+	}{
+		{"authenticated", "/xref.XrefService/GetXref", metadata.Pairs("x-api-key", "k-ops"), "api-key:ops", codes.OK},
+		{"unauthenticated", "/xref.XrefService/GetXref", metadata.MD{}, "", codes.Unauthenticated},
+		{"health check", "/grpc.health.v1.Health/Check", metadata.MD{}, "", codes.OK},
+		{"bearer without jwks", "/xref.XrefService/GetXref", metadata.Pairs("authorization", "Bearer x"), "", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			caller := ""
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if id, ok := FromContext(ctx); ok {
+					caller = id.String()
+				}
+				return nil, nil
+			}
+			_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %v, want %v (%v)", code, tt.code, err)
+			}
+			if caller != tt.caller {
+				t.Errorf("caller = %q, want %q", caller, tt.caller)
+			}
+		})
+	}
+}
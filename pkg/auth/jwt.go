@@ -0,0 +1,285 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // hashes of RS256 and ES256
+	_ "crypto/sha512" // hashes of RS384, RS512 and ES384
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// CLOCK_SKEW is the leeway given to the exp and nbf of tokens
+const CLOCK_SKEW time.Duration = 30 * time.Second
+
+// algorithms maps the signing algorithms accepted to their hash, symmetric
+// algorithms and none are refused
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+}
+
+// b64 decodes token segments, strictly so a token has a single encoding
+var b64 = base64.RawURLEncoding.Strict()
+
+// jwk is a public key of a JWKS file
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	key crypto.PublicKey
+}
+
+// loadJWKS reads the JWK set of path
+func loadJWKS(path string) ([]*jwk, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return keys, nil
+}
+
+// parseJWKS reads a JWK set of RSA and EC public keys
+func parseJWKS(b []byte) ([]*jwk, error) {
+
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+	var keys []*jwk
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var err error
+		if k.key, err = k.publicKey(); err != nil {
+			return nil, fmt.Errorf("key %d (kid %q): %v", i, k.Kid, err)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (k *jwk) publicKey() (crypto.PublicKey, error) {
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("n: %v", err)
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("e: %v", err)
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("e: out of range")
+		}
+		if n.BitLen() < 2048 {
+			return nil, errors.New("n: rsa keys must be at least 2048 bits")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %v", err)
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %v", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// claims are the registered claims checked
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	Expiry    *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+}
+
+// audience is the aud claim, a string or an array of them
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(a))
+}
+
+// verifyToken checks the signature and claims of a compact JWT
+func (a *Authenticator) verifyToken(token string) (Identity, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, errors.New("malformed")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, fmt.Errorf("header: %v", err)
+	}
+	hash, ok := algorithms[header.Alg]
+	if !ok {
+		return Identity{}, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("signature: %v", err)
+	}
+
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	digest := h.Sum(nil)
+
+	verified, known := false, false
+	for _, k := range a.jwks.Get() {
+		if header.Kid != "" && k.Kid != header.Kid {
+			continue
+		}
+		if k.Alg != "" && k.Alg != header.Alg {
+			continue
+		}
+		known = true
+		if verify(k.key, header.Alg, hash, digest, sig) {
+			verified = true
+			break
+		}
+	}
+	if !known {
+		return Identity{}, fmt.Errorf("no %s key %q", header.Alg, header.Kid)
+	}
+	if !verified {
+		return Identity{}, errors.New("bad signature")
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Identity{}, fmt.Errorf("claims: %v", err)
+	}
+	now := time.Now()
+	if c.Expiry == nil {
+		return Identity{}, errors.New("no expiry")
+	}
+	if now.After(time.Unix(*c.Expiry, 0).Add(CLOCK_SKEW)) {
+		return Identity{}, errors.New("expired")
+	}
+	if c.NotBefore != nil && now.Add(CLOCK_SKEW).Before(time.Unix(*c.NotBefore, 0)) {
+		return Identity{}, errors.New("not valid yet")
+	}
+	if a.cfg.Issuer != "" && c.Issuer != a.cfg.Issuer {
+		return Identity{}, fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if a.cfg.Audience != "" && !c.Audience.contains(a.cfg.Audience) {
+		return Identity{}, errors.New("not intended for this audience")
+	}
+	if c.Subject == "" {
+		return Identity{}, errors.New("no subject")
+	}
+	return Identity{Method: METHOD_JWT, Subject: c.Subject, Issuer: c.Issuer}, nil
+}
+
+func (a audience) contains(aud string) bool {
+	for _, s := range a {
+		if s == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// verify checks sig of digest by key under alg
+func verify(key crypto.PublicKey, alg string, hash crypto.Hash, digest, sig []byte) bool {
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(key, hash, digest, sig) == nil
+
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") || key.Curve.Params().BitSize != hash.Size()*8 {
+			return false
+		}
+		// the signature is r and s, each as long as the curve
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testKeys are the keys tokens are signed with, rsa and p256 are in the
+// JWKS and stranger isn't
+type testKeys struct {
+	rsa      *rsa.PrivateKey
+	p256     *ecdsa.PrivateKey
+	p384     *ecdsa.PrivateKey
+	stranger *rsa.PrivateKey
+}
+
+var keys = func() testKeys {
+	must := func(err error) {
+		if err != nil {
+			panic(err)
+		}
+	}
+	var k testKeys
+	var err error
+	k.rsa, err = rsa.GenerateKey(rand.Reader, 2048)
+	must(err)
+	k.stranger, err = rsa.GenerateKey(rand.Reader, 2048)
+	must(err)
+	k.p256, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	must(err)
+	k.p384, err = ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	must(err)
+	return k
+}()
+
+func enc(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func ecJWK(kid string, k *ecdsa.PrivateKey) map[string]string {
+	size := (k.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": k.Curve.Params().Name,
+		"x": enc(k.X.FillBytes(make([]byte, size))),
+		"y": enc(k.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+// testJWKS holds the rsa key as r1, pinned to RS256, the rsa key again as
+// r384, pinned to RS384, the p256 key as e1 and the p384 key as e384
+func testJWKS() map[string]interface{} {
+	rsaJWK := func(kid, alg string) map[string]string {
+		return map[string]string{
+			"kty": "RSA", "kid": kid, "alg": alg, "use": "sig",
+			"n": enc(keys.rsa.N.Bytes()),
+			"e": enc(big.NewInt(int64(keys.rsa.E)).Bytes()),
+		}
+	}
+	return map[string]interface{}{"keys": []map[string]string{
+		rsaJWK("r1", "RS256"),
+		rsaJWK("r384", "RS384"),
+		ecJWK("e1", keys.p256),
+		ecJWK("e384", keys.p384),
+		// encryption keys are ignored
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}}
+}
+
+func writeFile(t *testing.T, name string, v interface{}) string {
+	t.Helper()
+	var b []byte
+	switch v := v.(type) {
+	case string:
+		b = []byte(v)
+	default:
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestAuthenticator(t *testing.T, issuer, audience string) *Authenticator {
+	t.Helper()
+	a, err := NewAuthenticator(Config{
+		JWKS:     writeFile(t, "jwks.json", testJWKS()),
+		Issuer:   issuer,
+		Audience: audience,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// sign returns a compact JWT of header and claims signed by key under alg
+func sign(t *testing.T, header, claims map[string]interface{}, key crypto.Signer) string {
+	t.Helper()
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	input := enc(h) + "." + enc(c)
+
+	alg, _ := header["alg"].(string)
+	hash, ok := algorithms[alg]
+	if !ok {
+		hash = crypto.SHA256
+	}
+	hh := hash.New()
+	hh.Write([]byte(input))
+	digest := hh.Sum(nil)
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, digest); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return input + "." + enc(sig)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "alice",
+		"iss": "xref-idp",
+		"aud": "xref",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyTokenKeys(t *testing.T) {
+
+	a := newTestAuthenticator(t, "", "")
+
+	tests := []struct {
+		name   string
+		header map[string]interface{}
+		key    crypto.Signer
+		err    string
+	}{
+		{"rs256 by kid", map[string]interface{}{"alg": "RS256", "kid": "r1"}, keys.rsa, ""},
+		{"rs384 by kid", map[string]interface{}{"alg": "RS384", "kid": "r384"}, keys.rsa, ""},
+		{"es256 by kid", map[string]interface{}{"alg": "ES256", "kid": "e1"}, keys.p256, ""},
+		{"es384 by kid", map[string]interface{}{"alg": "ES384", "kid": "e384"}, keys.p384, ""},
+		{"rs256 without kid", map[string]interface{}{"alg": "RS256"}, keys.rsa, ""},
+		{"es256 without kid", map[string]interface{}{"alg": "ES256"}, keys.p256, ""},
+		{"unknown kid", map[string]interface{}{"alg": "RS256", "kid": "r2"}, keys.rsa, `no RS256 key "r2"`},
+		{"alg other than the key's", map[string]interface{}{"alg": "RS512", "kid": "r1"}, keys.rsa, `no RS512 key "r1"`},
+		{"ec alg on rsa kid", map[string]interface{}{"alg": "ES256", "kid": "r1"}, keys.p256, `no ES256 key "r1"`},
+		{"es256 on p384 key", map[string]interface{}{"alg": "ES256", "kid": "e384"}, keys.p256, "bad signature"},
+		{"key not in jwks", map[string]interface{}{"alg": "RS256", "kid": "r1"}, keys.stranger, "bad signature"},
+		{"none", map[string]interface{}{"alg": "none"}, keys.rsa, `unsupported algorithm "none"`},
+		{"hmac", map[string]interface{}{"alg": "HS256"}, keys.rsa, `unsupported algorithm "HS256"`},
+		{"encryption key", map[string]interface{}{"alg": "RS256", "kid": "enc"}, keys.rsa, `no RS256 key "enc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := a.verifyToken(sign(t, tt.header, validClaims(), tt.key))
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("verifyToken: %v", err)
+				}
+				if id.Method != METHOD_JWT || id.Subject != "alice" || id.Issuer != "xref-idp" {
+					t.Errorf("identity = %+v", id)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("err = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenClaims(t *testing.T) {
+
+	a := newTestAuthenticator(t, "xref-idp", "xref")
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		modify func(c map[string]interface{})
+		err    string
+	}{
+		{"valid", func(c map[string]interface{}) {}, ""},
+		{"expired", func(c map[string]interface{}) { c["exp"] = now.Add(-time.Minute).Unix() }, "expired"},
+		{"expired within skew", func(c map[string]interface{}) { c["exp"] = now.Add(-CLOCK_SKEW / 2).Unix() }, ""},
+		{"no expiry", func(c map[string]interface{}) { delete(c, "exp") }, "no expiry"},
+		{"not valid yet", func(c map[string]interface{}) { c["nbf"] = now.Add(time.Minute).Unix() }, "not valid yet"},
+		{"nbf within skew", func(c map[string]interface{}) { c["nbf"] = now.Add(CLOCK_SKEW / 2).Unix() }, ""},
+		{"nbf passed", func(c map[string]interface{}) { c["nbf"] = now.Add(-time.Minute).Unix() }, ""},
+		{"other issuer", func(c map[string]interface{}) { c["iss"] = "evil" }, `unexpected issuer "evil"`},
+		{"aud array", func(c map[string]interface{}) { c["aud"] = []string{"other", "xref"} }, ""},
+		{"aud array without us", func(c map[string]interface{}) { c["aud"] = []string{"other", "more"} }, "not intended for this audience"},
+		{"other aud", func(c map[string]interface{}) { c["aud"] = "other" }, "not intended for this audience"},
+		{"no aud", func(c map[string]interface{}) { delete(c, "aud") }, "not intended for this audience"},
+		{"no subject", func(c map[string]interface{}) { delete(c, "sub") }, "no subject"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(claims)
+			_, err := a.verifyToken(sign(t, map[string]interface{}{"alg": "RS256", "kid": "r1"}, claims, keys.rsa))
+			if tt.err == "" && err != nil {
+				t.Errorf("verifyToken: %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("err = %v, want %s", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenECSignatureLength(t *testing.T) {
+
+	a := newTestAuthenticator(t, "", "")
+	token := sign(t, map[string]interface{}{"alg": "ES256", "kid": "e1"}, validClaims(), keys.p256)
+	i := strings.LastIndex(token, ".")
+	input := token[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig) != 64 {
+		t.Fatalf("es256 signature is %d bytes, want 64", len(sig))
+	}
+
+	// r and s in asn.1, as crypto/ecdsa signs, rather than fixed width
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	der := append([]byte{0x30, 0}, asn1Int(r)...)
+	der = append(der, asn1Int(s)...)
+	der[1] = byte(len(der) - 2)
+
+	tests := []struct {
+		name string
+		sig  []byte
+	}{
+		{"short", sig[:63]},
+		{"long", append(append([]byte{}, sig...), 0)},
+		{"leading zero", append([]byte{0}, sig[:63]...)},
+		{"asn.1", der},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := a.verifyToken(input + "." + enc(tt.sig))
+			if err == nil || err.Error() != "bad signature" {
+				t.Errorf("err = %v, want bad signature", err)
+			}
+		})
+	}
+}
+
+func asn1Int(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return append([]byte{0x02, byte(len(b))}, b...)
+}
+
+func TestVerifyTokenMalformed(t *testing.T) {
+
+	a := newTestAuthenticator(t, "", "")
+	token := sign(t, map[string]interface{}{"alg": "RS256", "kid": "r1"}, validClaims(), keys.rsa)
+	parts := strings.Split(token, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"two segments", parts[0] + "." + parts[1]},
+		{"four segments", token + ".x"},
+		{"padded signature", token + "=="},
+		{"bad header", "!!." + parts[1] + "." + parts[2]},
+		{"claims swapped", parts[0] + "." + enc([]byte(`{"sub":"mallory","exp":9999999999}`)) + "." + parts[2]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := a.verifyToken(tt.token); err == nil {
+				t.Error("verifyToken accepted a malformed token")
+			}
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+
+	tests := []struct {
+		name string
+		jwks string
+		keys int
+		err  bool
+	}{
+		{"test keys", mustJSON(testJWKS()), 4, false},
+		{"no keys", `{"keys":[]}`, 0, true},
+		{"only encryption keys", `{"keys":[{"kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`, 0, true},
+		{"short rsa key", `{"keys":[{"kty":"RSA","n":"AQAB","e":"AQAB"}]}`, 0, true},
+		{"point off the curve", `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`, 0, true},
+		{"unsupported curve", `{"keys":[{"kty":"EC","crv":"P-521","x":"AQ","y":"AQ"}]}`, 0, true},
+		{"symmetric key", `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`, 0, true},
+		{"not json", `keys`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := parseJWKS([]byte(tt.jwks))
+			if tt.err {
+				if err == nil {
+					t.Errorf("parseJWKS accepted %s", tt.jwks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseJWKS: %v", err)
+			}
+			if len(keys) != tt.keys {
+				t.Errorf("%d keys, want %d", len(keys), tt.keys)
+			}
+		})
+	}
+}
+
+func mustJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
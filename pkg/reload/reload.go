@@ -0,0 +1,81 @@
+// Package reload keeps values parsed from files current, parsing them again
+// once the files change so they can be replaced without restarts.
+package reload
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// Value is parsed from a set of files. The files are checked on every Get
+// and parsed again when changed, a change that fails to parse keeps the
+// last good value in use.
+type Value[T any] struct {
+	name  string
+	paths []string
+	parse func() (T, error)
+
+	mu sync.Mutex
+	// stamp identifies the versions of the files last parsed
+	stamp string
+	value T
+}
+
+// New parses the value of paths, empty paths are left out. name prefixes
+// the reloads logged.
+func New[T any](name string, parse func() (T, error), paths ...string) (*Value[T], error) {
+
+	v := &Value[T]{name: name, parse: parse}
+	for _, path := range paths {
+		if path != "" {
+			v.paths = append(v.paths, path)
+		}
+	}
+
+	stamp, err := v.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+	if v.value, err = parse(); err != nil {
+		return nil, err
+	}
+	v.stamp = stamp
+	return v, nil
+}
+
+// fileStamp changes whenever one of the files does
+func (v *Value[T]) fileStamp() (string, error) {
+	var stamp string
+	for _, path := range v.paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%s:%d:%d;", path, fi.ModTime().UnixNano(), fi.Size())
+	}
+	return stamp, nil
+}
+
+// Get returns the value, parsed again when the files changed
+func (v *Value[T]) Get() T {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	stamp, err := v.fileStamp()
+	if err != nil || stamp == v.stamp {
+		// files being replaced may briefly be missing
+		return v.value
+	}
+	// a failed parse is only retried once the files change again
+	v.stamp = stamp
+	value, err := v.parse()
+	if err != nil {
+		log.Printf("%s: keeping previous value: %v", v.name, err)
+		return v.value
+	}
+	v.value = value
+	log.Printf("%s: reloaded", v.name)
+	return v.value
+}
@@ -0,0 +1,97 @@
+package reload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile writes content to path, moving its modification time forward so
+// the change is seen whatever the file system's time resolution
+func writeFile(t *testing.T, path, content string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValue(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "value")
+	mtime := time.Now().Add(-time.Hour)
+	writeFile(t, path, "one", mtime)
+
+	// values starting with "bad" don't parse
+	parses := 0
+	parse := func() (string, error) {
+		parses++
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(string(b), "bad") {
+			return "", errors.New("bad value")
+		}
+		return string(b), nil
+	}
+	v, err := New("test", parse, path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name    string
+		content string // written before Get when set
+		remove  bool
+		want    string
+		parses  int
+	}{
+		{name: "initial", want: "one", parses: 1},
+		{name: "unchanged", want: "one", parses: 1},
+		{name: "rewritten", content: "two", want: "two", parses: 2},
+		{name: "same size", content: "six", want: "six", parses: 3},
+		{name: "bad rewrite", content: "bad three", want: "six", parses: 4},
+		{name: "bad not retried", want: "six", parses: 4},
+		{name: "fixed", content: "four", want: "four", parses: 5},
+		{name: "removed", remove: true, want: "four", parses: 5},
+		{name: "restored", content: "five", want: "five", parses: 6},
+	}
+	for _, s := range steps {
+		switch {
+		case s.remove:
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+		case s.content != "":
+			mtime = mtime.Add(time.Second)
+			writeFile(t, path, s.content, mtime)
+		}
+		if got := v.Get(); got != s.want {
+			t.Errorf("%s: Get = %q, want %q", s.name, got, s.want)
+		}
+		if parses != s.parses {
+			t.Errorf("%s: %d parses, want %d", s.name, parses, s.parses)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "value")
+	writeFile(t, path, "bad", time.Now())
+	parse := func() (string, error) { return "", errors.New("bad value") }
+
+	if _, err := New("test", parse, path); err == nil || err.Error() != "bad value" {
+		t.Errorf("bad file: err = %v, want bad value", err)
+	}
+	if _, err := New("test", parse, filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing file: err = %v, want not exist", err)
+	}
+}
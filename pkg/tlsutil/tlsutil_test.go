@@ -0,0 +1,263 @@
+package tlsutil
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCert is a certificate and its key in PEM
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+var serial int64
+
+// newCert issues a certificate for cn signed by parent, self signed CA
+// certificates when parent is nil
+func newCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{cn},
+	}
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// files are the certificate files a reloader reads, rewritten with
+// increasing modification times so every write is seen
+type files struct {
+	t             *testing.T
+	cert, key, ca string
+	mtime         time.Time
+}
+
+func newFiles(t *testing.T) *files {
+	dir := t.TempDir()
+	return &files{
+		t:     t,
+		cert:  filepath.Join(dir, "tls.crt"),
+		key:   filepath.Join(dir, "tls.key"),
+		ca:    filepath.Join(dir, "ca.crt"),
+		mtime: time.Now().Add(-time.Hour),
+	}
+}
+
+func (f *files) write(path string, content []byte) {
+	f.t.Helper()
+	f.mtime = f.mtime.Add(time.Second)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.Chtimes(path, f.mtime, f.mtime); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// connPair returns the two ends of a loopback connection, buffered unlike
+// net.Pipe so neither side of a handshake blocks on the other's writes
+func connPair(t *testing.T) (client, server net.Conn) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client, err = net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if server, err = l.Accept(); err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+// handshake connects client to server, returning the certificate the
+// server presented
+func handshake(t *testing.T, server *tls.Config, client *tls.Config) (*x509.Certificate, error) {
+	t.Helper()
+	c, s := connPair(t)
+	defer c.Close()
+	defer s.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		conn := tls.Server(s, server)
+		done <- conn.Handshake()
+		conn.Close()
+	}()
+	conn := tls.Client(c, client)
+	err := conn.Handshake()
+	if err != nil {
+		c.Close()
+		<-done
+		return nil, err
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	return conn.ConnectionState().PeerCertificates[0], nil
+}
+
+func TestServerConfigReload(t *testing.T) {
+
+	ca := newCert(t, "ca", nil)
+	first := newCert(t, "xref", ca)
+	second := newCert(t, "xref", ca)
+
+	f := newFiles(t)
+	f.write(f.cert, first.certPEM)
+	f.write(f.key, first.keyPEM)
+	r, err := NewReloader(f.cert, f.key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := ServerConfig(r, tls.NoClientCert)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	client := &tls.Config{RootCAs: roots, ServerName: "xref"}
+
+	served := func(step string, want *testCert) {
+		t.Helper()
+		got, err := handshake(t, server, client)
+		if err != nil {
+			t.Fatalf("%s: handshake: %v", step, err)
+		}
+		if got.SerialNumber.Cmp(want.cert.SerialNumber) != 0 {
+			t.Errorf("%s: served certificate %v, want %v", step, got.SerialNumber, want.cert.SerialNumber)
+		}
+	}
+
+	served("initial", first)
+
+	f.write(f.cert, second.certPEM)
+	f.write(f.key, second.keyPEM)
+	served("rotated", second)
+
+	// a key not matching the certificate, as while the files are replaced
+	// one at a time, keeps the last good pair
+	f.write(f.cert, first.certPEM)
+	served("mismatched key", second)
+
+	f.write(f.cert, []byte("not a certificate"))
+	served("garbage", second)
+
+	f.write(f.cert, first.certPEM)
+	f.write(f.key, first.keyPEM)
+	served("restored", first)
+}
+
+func TestClientCredentialsReload(t *testing.T) {
+
+	oldCA := newCert(t, "old ca", nil)
+	newCA := newCert(t, "new ca", nil)
+	server := newCert(t, "xref", newCA)
+
+	f := newFiles(t)
+	f.write(f.ca, oldCA.certPEM)
+	r, err := NewReloader("", "", f.ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := ClientCredentials(r, "xref")
+
+	pair, err := tls.X509KeyPair(server.certPEM, server.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+
+	connect := func() error {
+		c, s := connPair(t)
+		defer c.Close()
+		defer s.Close()
+		go func() {
+			conn := tls.Server(s, serverConfig)
+			conn.Handshake()
+			conn.Close()
+		}()
+		conn, _, err := creds.ClientHandshake(context.Background(), "xref:50051", c)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	if err := connect(); err == nil {
+		t.Errorf("server of another CA verified")
+	}
+	f.write(f.ca, newCA.certPEM)
+	if err := connect(); err != nil {
+		t.Errorf("rotated CA: %v", err)
+	}
+	f.write(f.ca, []byte("not a certificate"))
+	if err := connect(); err != nil {
+		t.Errorf("bad CA rewrite: %v", err)
+	}
+}
+
+func TestNewReloader(t *testing.T) {
+
+	f := newFiles(t)
+	f.write(f.cert, []byte("not a certificate"))
+	f.write(f.key, []byte("not a key"))
+
+	if _, err := NewReloader(f.cert, "", ""); err == nil {
+		t.Errorf("certificate without key accepted")
+	}
+	if _, err := NewReloader(f.cert, f.key, ""); err == nil {
+		t.Errorf("bad certificate accepted")
+	}
+	if _, err := NewReloader("", "", f.cert); err == nil {
+		t.Errorf("bad CA bundle accepted")
+	}
+}
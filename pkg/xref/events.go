@@ -0,0 +1,317 @@
+package xref
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// EVENTS is the redis stream of xref events
+	EVENTS = "events"
+
+	// eventsMaxLen is the approximate number of events kept for consumers
+	// resuming from a last seen id
+	eventsMaxLen = 100000
+
+	// eventsBlock is how long the feed's read waits for new events before
+	// checking whether the server stopped
+	eventsBlock = 2 * time.Second
+
+	// eventsBatch is the number of events read at a time
+	eventsBatch = 100
+
+	// eventsBuffer is the number of events queued for a watcher before it is
+	// dropped from the feed and catches up from the stream
+	eventsBuffer = 256
+)
+
+// addEvent appends an xref event to the events stream on pipe
+func (x *xrefServer) addEvent(ctx context.Context, pipe redis.Pipeliner, t XrefEvent_TYPE, lastFour, val, magicNum string, at time.Time) {
+	pipe.XAdd(ctx, &redis.XAddArgs{
+		Stream: x.key(EVENTS),
+		MaxLen: eventsMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type":     t.String(),
+			"lastfour": lastFour,
+			"xref":     val,
+			"magic":    magicNum,
+			"time":     at.UnixNano(),
+		},
+	})
+}
+
+// parseEvent converts a stream message to an XrefEvent
+func parseEvent(msg redis.XMessage) *XrefEvent {
+	str := func(k string) string {
+		s, _ := msg.Values[k].(string)
+		return s
+	}
+
+	event := &XrefEvent{
+		Id:          msg.ID,
+		Type:        XrefEvent_TYPE(XrefEvent_TYPE_value[str("type")]),
+		Lastfour:    str("lastfour"),
+		Token:       &XREF{Value: str("xref")},
+		MagicNumber: str("magic"),
+	}
+	if nanos, err := strconv.ParseInt(str("time"), 10, 64); err == nil {
+		event.OccurredAt = timestamppb.New(time.Unix(0, nanos))
+	}
+	return event
+}
+
+// streamID is a parsed redis stream id
+type streamID struct {
+	ms, seq uint64
+}
+
+// parseStreamID parses a stream id of the form <ms>-<seq>
+func parseStreamID(id string) (streamID, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		return streamID{}, fmt.Errorf("bad event id %q", id)
+	}
+	var sid streamID
+	var err error
+	if sid.ms, err = strconv.ParseUint(ms, 10, 64); err != nil {
+		return streamID{}, fmt.Errorf("bad event id %q", id)
+	}
+	if sid.seq, err = strconv.ParseUint(seq, 10, 64); err != nil {
+		return streamID{}, fmt.Errorf("bad event id %q", id)
+	}
+	return sid, nil
+}
+
+func (a streamID) less(b streamID) bool {
+	return a.ms < b.ms || a.ms == b.ms && a.seq < b.seq
+}
+
+func (a streamID) String() string {
+	return strconv.FormatUint(a.ms, 10) + "-" + strconv.FormatUint(a.seq, 10)
+}
+
+// eventFeed reads the events stream once for every watcher, so watchers
+// don't each hold a pool connection in a blocking read
+type eventFeed struct {
+	mu      sync.Mutex
+	running bool
+	subs    map[chan redis.XMessage]struct{}
+}
+
+// subscribe registers a channel receiving every event added from now on,
+// starting the feed on first use. The channel is closed when the watcher
+// falls eventsBuffer events behind.
+func (x *xrefServer) subscribe() (chan redis.XMessage, error) {
+	f := x.events
+	ch := make(chan redis.XMessage, eventsBuffer)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.running {
+		// the feed starts after the newest event before any watcher relies
+		// on it, so none are missed in between
+		last, err := x.newestEvent(x.ctx)
+		if err != nil {
+			return nil, err
+		}
+		f.running = true
+		go x.readEvents(last)
+	}
+	f.subs[ch] = struct{}{}
+	return ch, nil
+}
+
+func (x *xrefServer) unsubscribe(ch chan redis.XMessage) {
+	f := x.events
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.subs, ch)
+}
+
+// newestEvent returns the id of the newest event, 0-0 when there are none
+func (x *xrefServer) newestEvent(ctx context.Context) (string, error) {
+	newest, err := x.redis.XRevRangeN(ctx, x.key(EVENTS), "+", "-", 1).Result()
+	if err != nil {
+		return "", err
+	}
+	if len(newest) == 0 {
+		return "0-0", nil
+	}
+	return newest[0].ID, nil
+}
+
+// readEvents reads events after lastID until the server stops, passing
+// them on to every subscriber
+func (x *xrefServer) readEvents(lastID string) {
+	f := x.events
+	for x.ctx.Err() == nil {
+		res, err := x.redis.XRead(x.ctx, &redis.XReadArgs{
+			Streams: []string{x.key(EVENTS), lastID},
+			Count:   eventsBatch,
+			Block:   eventsBlock,
+		}).Result()
+		if err == redis.Nil || x.ctx.Err() != nil {
+			continue
+		}
+		if err != nil {
+			log.Printf("event feed: %v", err)
+			select {
+			case <-x.ctx.Done():
+			case <-time.After(eventsBlock):
+			}
+			continue
+		}
+
+		f.mu.Lock()
+		for _, s := range res {
+			for _, msg := range s.Messages {
+				for ch := range f.subs {
+					select {
+					case ch <- msg:
+					default:
+						// too far behind, the watcher catches up from the
+						// stream itself
+						delete(f.subs, ch)
+						close(ch)
+					}
+				}
+				lastID = msg.ID
+			}
+		}
+		f.mu.Unlock()
+	}
+}
+
+// WatchXrefs streams xref events, starting after last_event_id when set.
+// Events older than the last eventsMaxLen are no longer available to resume
+// from.
+func (x *xrefServer) WatchXrefs(in *WatchXrefsRequest, stream XrefService_WatchXrefsServer) error {
+
+	ctx, cancel := x.untilShutdown(stream.Context())
+	defer cancel()
+
+	var last streamID
+	if in.GetLastEventId() == "" {
+		newest, err := x.newestEvent(ctx)
+		if err != nil {
+			return err
+		}
+		if last, err = parseStreamID(newest); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if last, err = parseStreamID(in.GetLastEventId()); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		// events between last and the oldest kept one may have been trimmed
+		oldest, err := x.redis.XRangeN(ctx, x.key(EVENTS), "-", "+", 1).Result()
+		if err != nil {
+			return err
+		}
+		if len(oldest) > 0 {
+			first, err := parseStreamID(oldest[0].ID)
+			if err != nil {
+				return err
+			}
+			if last.less(first) {
+				return status.Errorf(codes.OutOfRange, "event %s is no longer kept, the oldest is %s", last, first)
+			}
+		}
+	}
+
+	// send sends msg unless it was sent already
+	send := func(msg redis.XMessage) error {
+		id, err := parseStreamID(msg.ID)
+		if err != nil {
+			return err
+		}
+		if !last.less(id) {
+			return nil
+		}
+		if err := stream.Send(parseEvent(msg)); err != nil {
+			return err
+		}
+		last = id
+		return nil
+	}
+
+	for {
+		// subscribing before catching up leaves no gap between the two,
+		// events seen in both are sent once
+		sub, err := x.subscribe()
+		if err != nil {
+			return err
+		}
+		err = x.catchUp(ctx, &last, send)
+		if err == nil {
+			err = x.follow(ctx, sub, send)
+		}
+		x.unsubscribe(sub)
+		if err != nil || x.ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+// catchUp sends the events in the stream after *last
+func (x *xrefServer) catchUp(ctx context.Context, last *streamID, send func(redis.XMessage) error) error {
+	for {
+		// the range starts at last itself, which send skips
+		msgs, err := x.redis.XRangeN(ctx, x.key(EVENTS), last.String(), "+", eventsBatch).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return x.watchEnded(ctx)
+			}
+			return err
+		}
+		for _, msg := range msgs {
+			if err := send(msg); err != nil {
+				return err
+			}
+		}
+		if len(msgs) < eventsBatch {
+			return nil
+		}
+	}
+}
+
+// follow sends the events of the feed until the watcher falls behind,
+// which returns nil to catch up again
+func (x *xrefServer) follow(ctx context.Context, sub chan redis.XMessage, send func(redis.XMessage) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return x.watchEnded(ctx)
+		case <-x.ctx.Done():
+			return nil
+		case msg, ok := <-sub:
+			if !ok {
+				return nil
+			}
+			if err := send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchEnded is the error ending a watch whose ctx is done
+func (x *xrefServer) watchEnded(ctx context.Context) error {
+	if x.shuttingDown() {
+		return errShuttingDown
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,121 @@
+package xref
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthConfig configures the checks driving the health service
+type HealthConfig struct {
+	// Interval between checks
+	Interval time.Duration
+	// MaxLatency is the redis ping latency above which the server is not
+	// serving, 0 disables the limit
+	MaxLatency time.Duration
+}
+
+// POOL_HEALTH_SERVICE is the health service reporting whether magic numbers
+// are available. The pool is shared by every server, so its exhaustion
+// doesn't take XrefService out of balancing, lookups and stats still work.
+const POOL_HEALTH_SERVICE string = "xref.XrefService.pool"
+
+// StartHealthChecks checks the store every interval, setting the status of
+// XrefService and of the server as a whole on hs. The server is serving
+// while redis answers pings in time and the data was initialized. Whether
+// magic numbers are available is reported as POOL_HEALTH_SERVICE.
+func (x *xrefServer) StartHealthChecks(hs *health.Server, cfg HealthConfig) error {
+
+	if cfg.Interval <= 0 {
+		return errors.New("health check interval must be > 0")
+	}
+
+	x.health = hs
+	x.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus(POOL_HEALTH_SERVICE, healthpb.HealthCheckResponse_NOT_SERVING)
+	go func() {
+		t := time.NewTicker(cfg.Interval)
+		defer t.Stop()
+
+		var last error
+		lastEmpty := false
+		for {
+			empty, err := x.check(cfg)
+			switch {
+			case err != nil && (last == nil || err.Error() != last.Error()):
+				log.Printf("health: not serving: %v", err)
+			case err == nil && last != nil:
+				log.Printf("health: serving")
+			}
+			last = err
+
+			if err != nil {
+				x.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+				hs.SetServingStatus(POOL_HEALTH_SERVICE, healthpb.HealthCheckResponse_NOT_SERVING)
+			} else {
+				x.setServing(healthpb.HealthCheckResponse_SERVING)
+				if empty != lastEmpty {
+					if empty {
+						log.Printf("health: no magic numbers available")
+					} else {
+						log.Printf("health: magic numbers available")
+					}
+				}
+				lastEmpty = empty
+				if empty {
+					hs.SetServingStatus(POOL_HEALTH_SERVICE, healthpb.HealthCheckResponse_NOT_SERVING)
+				} else {
+					hs.SetServingStatus(POOL_HEALTH_SERVICE, healthpb.HealthCheckResponse_SERVING)
+				}
+			}
+
+			select {
+			case <-x.ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// check returns why the server can't serve, or nil when it can, and
+// whether the pool of magic numbers is empty
+func (x *xrefServer) check(cfg HealthConfig) (bool, error) {
+
+	if atomic.LoadInt32(&x.initialized) == 0 {
+		return false, errors.New("data not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(x.ctx, cfg.Interval)
+	defer cancel()
+
+	start := time.Now()
+	if err := x.redis.Ping(ctx).Err(); err != nil {
+		return false, fmt.Errorf("redis unreachable: %v", err)
+	}
+	if latency := time.Since(start); cfg.MaxLatency > 0 && latency > cfg.MaxLatency {
+		return false, fmt.Errorf("redis ping took %v, above %v", latency.Round(time.Millisecond), cfg.MaxLatency)
+	}
+
+	available, err := x.redis.LLen(ctx, x.key(AVAILABLE)).Result()
+	if err != nil {
+		return false, fmt.Errorf("redis unreachable: %v", err)
+	}
+	return available == 0, nil
+}
+
+// setServing sets the status of XrefService and of the server as a whole
+func (x *xrefServer) setServing(s healthpb.HealthCheckResponse_ServingStatus) {
+	if x.health == nil {
+		return
+	}
+	x.health.SetServingStatus("", s)
+	x.health.SetServingStatus(XrefService_ServiceDesc.ServiceName, s)
+}
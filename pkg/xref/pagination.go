@@ -0,0 +1,99 @@
+package xref
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scanBatch is the number of entries read from redis per LRANGE/HSCAN call
+const scanBatch = 500
+
+// pageToken marks where a magic number listing left off. For the available
+// list cursor is an offset into the list, for the unavailable hash it is the
+// HSCAN cursor of the batch and after the last magic number of that batch
+// already sent. Entries deleted meanwhile shift positions in the batch, so it
+// resumes from a field rather than a count.
+type pageToken struct {
+	cursor uint64
+	after  string
+}
+
+func parsePageToken(s string) (pageToken, error) {
+	var t pageToken
+	if s == "" {
+		return t, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return t, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	cursor, after, ok := strings.Cut(string(b), ":")
+	if !ok {
+		return t, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	if t.cursor, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+		return t, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	t.after = after
+	return t, nil
+}
+
+func (t pageToken) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(t.cursor, 10) + ":" + t.after))
+}
+
+// magicNumberPage sends magic numbers one behind, so the last one of the
+// listing can be sent without a page token
+type magicNumberPage struct {
+	stream  XrefService_GetMagicNumbersServer
+	size    int
+	n       int
+	pending *MagicNumber
+}
+
+// full reports whether the page holds page_size magic numbers
+func (p *magicNumberPage) full() bool {
+	return p.size > 0 && p.n >= p.size
+}
+
+// add queues mn and sends the magic number queued before it
+func (p *magicNumberPage) add(mn *MagicNumber) error {
+	if p.pending != nil {
+		if err := p.stream.Send(p.pending); err != nil {
+			return err
+		}
+	}
+	p.pending = mn
+	p.n++
+	return nil
+}
+
+// close sends the last queued magic number, clearing its page token when
+// nothing follows it
+func (p *magicNumberPage) close(more bool) error {
+	if p.pending == nil {
+		return nil
+	}
+	if !more {
+		p.pending.PageToken = ""
+	}
+	return p.stream.Send(p.pending)
+}
+
+// matchPrefix builds a SCAN MATCH pattern for keys starting with prefix
+func matchPrefix(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('*')
+	return b.String()
+}
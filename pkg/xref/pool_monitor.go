@@ -0,0 +1,235 @@
+package xref
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// PoolMonitorConfig configures how the available pool is watched
+type PoolMonitorConfig struct {
+	// Interval between pool level checks
+	Interval time.Duration
+	// Low and Critical are the available counts at or below which the pool
+	// is LOW or CRITICAL, 0 disables the level
+	Low      uint64
+	Critical uint64
+	// Webhook receives a JSON PoolLevel each time the level changes, a
+	// level other than OK at startup counting as a change
+	Webhook string
+}
+
+// subscriberBuffer is the number of pool levels queued for a slow subscriber
+// before the oldest is dropped
+const subscriberBuffer = 16
+
+type poolMonitor struct {
+	cfg    PoolMonitorConfig
+	client *http.Client
+	alerts chan *PoolLevel
+
+	mu   sync.Mutex
+	last *PoolLevel
+	subs map[chan *PoolLevel]struct{}
+}
+
+// StartPoolMonitor checks the available pool every interval, pushing its
+// level to WatchPoolLevel subscribers and the webhook
+func (x *xrefServer) StartPoolMonitor(cfg PoolMonitorConfig) error {
+
+	if cfg.Interval <= 0 {
+		return errors.New("pool monitor interval must be > 0")
+	}
+	if cfg.Low > 0 && cfg.Critical > cfg.Low {
+		return errors.New("critical watermark must be <= low watermark")
+	}
+
+	m := &poolMonitor{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+		alerts: make(chan *PoolLevel, subscriberBuffer),
+		subs:   make(map[chan *PoolLevel]struct{}),
+	}
+	x.monitor = m
+
+	if cfg.Webhook != "" {
+		go m.sendAlerts(x.ctx)
+	}
+	go func() {
+		t := time.NewTicker(cfg.Interval)
+		defer t.Stop()
+		for {
+			available, err := x.redis.LLen(x.ctx, x.key(AVAILABLE)).Uint64()
+			if err != nil {
+				log.Printf("pool monitor: %v", err)
+			} else {
+				m.observe(available)
+			}
+
+			select {
+			case <-x.ctx.Done():
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// level maps an available count to its pool level
+func (m *poolMonitor) level(available uint64) PoolLevel_LEVEL {
+	switch {
+	case available == 0:
+		return PoolLevel_EXHAUSTED
+	case available <= m.cfg.Critical:
+		return PoolLevel_CRITICAL
+	case available <= m.cfg.Low:
+		return PoolLevel_LOW
+	default:
+		return PoolLevel_OK
+	}
+}
+
+// observe publishes the pool level for available to subscribers, queueing
+// an alert when the level changed
+func (m *poolMonitor) observe(available uint64) {
+
+	level := &PoolLevel{
+		Available:  available,
+		Level:      m.level(available),
+		ObservedAt: timestamppb.Now(),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the pool is taken to be OK before the first observation, so a server
+	// starting on a healthy pool sends no alert
+	level.PreviousLevel = PoolLevel_OK
+	if m.last != nil {
+		level.PreviousLevel = m.last.Level
+	}
+	changed := level.Level != level.PreviousLevel
+	m.last = level
+
+	for sub := range m.subs {
+		publish(sub, level)
+	}
+	if changed && m.cfg.Webhook != "" {
+		publish(m.alerts, level)
+	}
+}
+
+// publish queues level on ch, dropping the oldest queued level when full
+func publish(ch chan *PoolLevel, level *PoolLevel) {
+	for {
+		select {
+		case ch <- level:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// subscribe registers a channel receiving every pool level, starting with
+// the latest one
+func (m *poolMonitor) subscribe() chan *PoolLevel {
+	ch := make(chan *PoolLevel, subscriberBuffer)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.last != nil {
+		ch <- m.last
+	}
+	m.subs[ch] = struct{}{}
+	return ch
+}
+
+func (m *poolMonitor) unsubscribe(ch chan *PoolLevel) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.subs, ch)
+}
+
+// sendAlerts posts queued level changes to the webhook
+func (m *poolMonitor) sendAlerts(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case level := <-m.alerts:
+			if err := m.post(ctx, level); err != nil {
+				log.Printf("pool alert webhook: %v", err)
+			}
+		}
+	}
+}
+
+func (m *poolMonitor) post(ctx context.Context, level *PoolLevel) error {
+
+	body, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(level)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.cfg.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return errors.New("unexpected status " + res.Status)
+	}
+	return nil
+}
+
+// WatchPoolLevel streams the pool level as the monitor observes it
+func (x *xrefServer) WatchPoolLevel(in *PoolLevelRequest, stream XrefService_WatchPoolLevelServer) error {
+
+	if x.monitor == nil {
+		return status.Error(codes.Unavailable, "pool monitor not running")
+	}
+
+	sub := x.monitor.subscribe()
+	defer x.monitor.unsubscribe(sub)
+
+	// the current level is always sent first
+	first := true
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-x.ctx.Done():
+			return nil
+		case <-x.closing:
+			return errShuttingDown
+		case level := <-sub:
+			if in.GetChangesOnly() && !first && level.Level == level.PreviousLevel {
+				continue
+			}
+			if err := stream.Send(level); err != nil {
+				return err
+			}
+			first = false
+		}
+	}
+}
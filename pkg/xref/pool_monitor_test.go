@@ -0,0 +1,126 @@
+package xref
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// webhook stands in for the alert receiver, passing on every payload
+func webhook(t *testing.T) (*httptest.Server, chan *PoolLevel) {
+	t.Helper()
+	received := make(chan *PoolLevel, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("content type = %q, want application/json", ct)
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("read body: %v", err)
+			return
+		}
+		level := &PoolLevel{}
+		if err := protojson.Unmarshal(body, level); err != nil {
+			t.Errorf("payload %s: %v", body, err)
+			return
+		}
+		received <- level
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func newTestMonitor(t *testing.T, url string) *poolMonitor {
+	t.Helper()
+	m := &poolMonitor{
+		cfg:    PoolMonitorConfig{Interval: time.Second, Low: 10, Critical: 3, Webhook: url},
+		client: &http.Client{Timeout: time.Second},
+		alerts: make(chan *PoolLevel, subscriberBuffer),
+		subs:   make(map[chan *PoolLevel]struct{}),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go m.sendAlerts(ctx)
+	return m
+}
+
+func TestPoolMonitorAlertsOnLevelChanges(t *testing.T) {
+
+	srv, received := webhook(t)
+	m := newTestMonitor(t, srv.URL)
+
+	type alert struct {
+		available       uint64
+		level, previous PoolLevel_LEVEL
+	}
+	want := []alert{
+		{8, PoolLevel_LOW, PoolLevel_OK},
+		{2, PoolLevel_CRITICAL, PoolLevel_LOW},
+		{0, PoolLevel_EXHAUSTED, PoolLevel_CRITICAL},
+		{50, PoolLevel_OK, PoolLevel_EXHAUSTED},
+	}
+	// repeated levels must not alert
+	for _, available := range []uint64{100, 90, 8, 7, 2, 1, 0, 0, 50} {
+		m.observe(available)
+	}
+
+	for i, w := range want {
+		select {
+		case got := <-received:
+			if got.Available != w.available || got.Level != w.level || got.PreviousLevel != w.previous {
+				t.Errorf("alert %d = %d %v from %v, want %d %v from %v",
+					i, got.Available, got.Level, got.PreviousLevel, w.available, w.level, w.previous)
+			}
+			if got.ObservedAt == nil {
+				t.Errorf("alert %d has no observed_at", i)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("alert %d not received", i)
+		}
+	}
+	select {
+	case got := <-received:
+		t.Errorf("unexpected alert %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPoolMonitorStartupAlert(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		available uint64
+		alert     bool
+	}{
+		{"ok pool", 100, false},
+		{"critical pool", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, received := webhook(t)
+			m := newTestMonitor(t, srv.URL)
+			m.observe(tt.available)
+
+			select {
+			case got := <-received:
+				if !tt.alert {
+					t.Errorf("unexpected alert %v", got)
+				} else if got.PreviousLevel != PoolLevel_OK {
+					t.Errorf("previous level = %v, want OK", got.PreviousLevel)
+				}
+			case <-time.After(200 * time.Millisecond):
+				if tt.alert {
+					t.Errorf("no alert")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package xref
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errShuttingDown ends watch streams when the server stops, clients can
+// resume them on another server
+var errShuttingDown = status.Error(codes.Unavailable, "server shutting down")
+
+// Shutdown prepares the service for the server to stop. The service is
+// reported as not serving from then on, and watch streams, which never end
+// on their own, are ended. Allocations in flight carry on.
+func (x *xrefServer) Shutdown() {
+	x.closeOnce.Do(func() {
+		close(x.closing)
+		if x.health != nil {
+			x.health.Shutdown()
+		}
+	})
+}
+
+// untilShutdown returns a copy of ctx cancelled by Shutdown too
+func (x *xrefServer) untilShutdown(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-x.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// shuttingDown reports whether Shutdown was called
+func (x *xrefServer) shuttingDown() bool {
+	select {
+	case <-x.closing:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,112 @@
+package xref
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// ALLOCATIONS counts every magic number allocated, per minute counts
+	// are kept in ALLOCATIONS:<unix minute>
+	ALLOCATIONS = "allocations"
+
+	// exhaustionWindow is the rate window used to estimate exhaustion
+	exhaustionWindow = 15
+)
+
+// rateWindows are the allocation rate windows in minutes
+var rateWindows = []int{1, 5, exhaustionWindow, 60}
+
+// maxRateWindow is how long per minute counters are kept
+const maxRateWindow = 60
+
+func (x *xrefServer) allocationsKey(minute int64) string {
+	return fmt.Sprintf("%s:%d", x.key(ALLOCATIONS), minute)
+}
+
+// countAllocation records an allocation at t on pipe
+func (x *xrefServer) countAllocation(ctx context.Context, pipe redis.Pipeliner, t time.Time) {
+	key := x.allocationsKey(t.Unix() / 60)
+	pipe.Incr(ctx, x.key(ALLOCATIONS))
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, (maxRateWindow+1)*time.Minute)
+}
+
+// resetAllocations clears the allocation counters
+func (x *xrefServer) resetAllocations() error {
+	keys := []string{x.key(ALLOCATIONS)}
+	now := time.Now().Unix() / 60
+	for i := int64(0); i <= maxRateWindow; i++ {
+		keys = append(keys, x.allocationsKey(now-i))
+	}
+	return x.redis.Del(x.ctx, keys...).Err()
+}
+
+// GetPoolStats returns counts for every magic number state along with
+// recent allocation rates
+func (x *xrefServer) GetPoolStats(ctx context.Context, in *PoolStatsRequest) (*PoolStats, error) {
+
+	// per minute counters, most recent first
+	now := time.Now().Unix() / 60
+	keys := make([]string, maxRateWindow)
+	for i := range keys {
+		keys[i] = x.allocationsKey(now - int64(i))
+	}
+
+	var (
+		available, unavailable, retired, xrefs *redis.IntCmd
+		total                                  *redis.StringCmd
+		minutes                                *redis.SliceCmd
+	)
+	_, err := x.redis.Pipelined(x.ctx, func(pipe redis.Pipeliner) error {
+		available = pipe.LLen(x.ctx, x.key(AVAILABLE))
+		unavailable = pipe.HLen(x.ctx, x.key(UNAVAILABLE))
+		retired = pipe.HLen(x.ctx, x.key(RETIRED))
+		xrefs = pipe.HLen(x.ctx, x.key(XMAP))
+		total = pipe.Get(x.ctx, x.key(ALLOCATIONS))
+		minutes = pipe.MGet(x.ctx, keys...)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	totalAllocations, _ := total.Uint64()
+	stats := &PoolStats{
+		Available:        uint64(available.Val()),
+		Unavailable:      uint64(unavailable.Val()),
+		Retired:          uint64(retired.Val()),
+		Xrefs:            uint64(xrefs.Val()),
+		TotalAllocations: totalAllocations,
+	}
+
+	// sum the per minute counters into each window
+	var counts [maxRateWindow]int64
+	for i, v := range minutes.Val() {
+		if s, ok := v.(string); ok {
+			fmt.Sscan(s, &counts[i])
+		}
+	}
+	for _, w := range rateWindows {
+		var sum int64
+		for _, n := range counts[:w] {
+			sum += n
+		}
+		rate := float64(sum) / float64(w)
+		stats.AllocationRates = append(stats.AllocationRates, &AllocationRate{
+			WindowMinutes: uint32(w),
+			PerMinute:     rate,
+		})
+
+		if w == exhaustionWindow && rate > 0 {
+			eta := time.Duration(float64(stats.Available) / rate * float64(time.Minute))
+			stats.EstimatedTimeToExhaustion = durationpb.New(eta)
+		}
+	}
+
+	return stats, nil
+}
@@ -7,8 +7,12 @@
 package xref
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	status "google.golang.org/genproto/googleapis/rpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 )
@@ -20,6 +24,108 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type PoolLevel_LEVEL int32
+
+const (
+	PoolLevel_OK        PoolLevel_LEVEL = 0
+	PoolLevel_LOW       PoolLevel_LEVEL = 1
+	PoolLevel_CRITICAL  PoolLevel_LEVEL = 2
+	PoolLevel_EXHAUSTED PoolLevel_LEVEL = 3
+)
+
+// Enum value maps for PoolLevel_LEVEL.
+var (
+	PoolLevel_LEVEL_name = map[int32]string{
+		0: "OK",
+		1: "LOW",
+		2: "CRITICAL",
+		3: "EXHAUSTED",
+	}
+	PoolLevel_LEVEL_value = map[string]int32{
+		"OK":        0,
+		"LOW":       1,
+		"CRITICAL":  2,
+		"EXHAUSTED": 3,
+	}
+)
+
+func (x PoolLevel_LEVEL) Enum() *PoolLevel_LEVEL {
+	p := new(PoolLevel_LEVEL)
+	*p = x
+	return p
+}
+
+func (x PoolLevel_LEVEL) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PoolLevel_LEVEL) Descriptor() protoreflect.EnumDescriptor {
+	return file_xref_xref_proto_enumTypes[0].Descriptor()
+}
+
+func (PoolLevel_LEVEL) Type() protoreflect.EnumType {
+	return &file_xref_xref_proto_enumTypes[0]
+}
+
+func (x PoolLevel_LEVEL) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PoolLevel_LEVEL.Descriptor instead.
+func (PoolLevel_LEVEL) EnumDescriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type XrefEvent_TYPE int32
+
+const (
+	XrefEvent_CREATED XrefEvent_TYPE = 0
+	// the xref of a last 4 was replaced, token is the new one
+	XrefEvent_ROTATED XrefEvent_TYPE = 1
+	XrefEvent_DELETED XrefEvent_TYPE = 2
+)
+
+// Enum value maps for XrefEvent_TYPE.
+var (
+	XrefEvent_TYPE_name = map[int32]string{
+		0: "CREATED",
+		1: "ROTATED",
+		2: "DELETED",
+	}
+	XrefEvent_TYPE_value = map[string]int32{
+		"CREATED": 0,
+		"ROTATED": 1,
+		"DELETED": 2,
+	}
+)
+
+func (x XrefEvent_TYPE) Enum() *XrefEvent_TYPE {
+	p := new(XrefEvent_TYPE)
+	*p = x
+	return p
+}
+
+func (x XrefEvent_TYPE) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (XrefEvent_TYPE) Descriptor() protoreflect.EnumDescriptor {
+	return file_xref_xref_proto_enumTypes[1].Descriptor()
+}
+
+func (XrefEvent_TYPE) Type() protoreflect.EnumType {
+	return &file_xref_xref_proto_enumTypes[1]
+}
+
+func (x XrefEvent_TYPE) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use XrefEvent_TYPE.Descriptor instead.
+func (XrefEvent_TYPE) EnumDescriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{13, 0}
+}
+
 type Status_STATUS int32
 
 const (
@@ -50,11 +156,11 @@ func (x Status_STATUS) String() string {
 }
 
 func (Status_STATUS) Descriptor() protoreflect.EnumDescriptor {
-	return file_xref_xref_proto_enumTypes[0].Descriptor()
+	return file_xref_xref_proto_enumTypes[2].Descriptor()
 }
 
 func (Status_STATUS) Type() protoreflect.EnumType {
-	return &file_xref_xref_proto_enumTypes[0]
+	return &file_xref_xref_proto_enumTypes[2]
 }
 
 func (x Status_STATUS) Number() protoreflect.EnumNumber {
@@ -63,7 +169,7 @@ func (x Status_STATUS) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Status_STATUS.Descriptor instead.
 func (Status_STATUS) EnumDescriptor() ([]byte, []int) {
-	return file_xref_xref_proto_rawDescGZIP(), []int{6, 0}
+	return file_xref_xref_proto_rawDescGZIP(), []int{14, 0}
 }
 
 type XrefRequest struct {
@@ -118,7 +224,12 @@ type XrefResponse struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Token *XREF `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Token    *XREF  `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Lastfour string `protobuf:"bytes,2,opt,name=lastfour,proto3" json:"lastfour,omitempty"`
+	// set instead of token when GetXrefs fails for lastfour
+	Error *status.Status `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// the xref was allocated by this request
+	Created bool `protobuf:"varint,4,opt,name=created,proto3" json:"created,omitempty"`
 }
 
 func (x *XrefResponse) Reset() {
@@ -160,6 +271,27 @@ func (x *XrefResponse) GetToken() *XREF {
 	return nil
 }
 
+func (x *XrefResponse) GetLastfour() string {
+	if x != nil {
+		return x.Lastfour
+	}
+	return ""
+}
+
+func (x *XrefResponse) GetError() *status.Status {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *XrefResponse) GetCreated() bool {
+	if x != nil {
+		return x.Created
+	}
+	return false
+}
+
 type XREF struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -214,7 +346,9 @@ type XrefSummary struct {
 
 	TotalNew     uint32 `protobuf:"varint,1,opt,name=total_new,json=totalNew,proto3" json:"total_new,omitempty"`
 	TotalUpdated uint32 `protobuf:"varint,2,opt,name=total_updated,json=totalUpdated,proto3" json:"total_updated,omitempty"`
-	ElapsedTime  uint32 `protobuf:"varint,3,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
+	// milliseconds
+	ElapsedTime uint32 `protobuf:"varint,3,opt,name=elapsed_time,json=elapsedTime,proto3" json:"elapsed_time,omitempty"`
+	TotalFailed uint32 `protobuf:"varint,4,opt,name=total_failed,json=totalFailed,proto3" json:"total_failed,omitempty"`
 }
 
 func (x *XrefSummary) Reset() {
@@ -270,12 +404,23 @@ func (x *XrefSummary) GetElapsedTime() uint32 {
 	return 0
 }
 
+func (x *XrefSummary) GetTotalFailed() uint32 {
+	if x != nil {
+		return x.TotalFailed
+	}
+	return 0
+}
+
 type MagicNumber struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	// resumes the listing after this magic number, empty when it is the last
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// set when details are requested
+	Detail *MagicNumberDetail `protobuf:"bytes,3,opt,name=detail,proto3" json:"detail,omitempty"`
 }
 
 func (x *MagicNumber) Reset() {
@@ -317,6 +462,91 @@ func (x *MagicNumber) GetValue() string {
 	return ""
 }
 
+func (x *MagicNumber) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *MagicNumber) GetDetail() *MagicNumberDetail {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+type MagicNumberDetail struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Lastfour    string                 `protobuf:"bytes,1,opt,name=lastfour,proto3" json:"lastfour,omitempty"`
+	Token       *XREF                  `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Status      Status_STATUS          `protobuf:"varint,3,opt,name=status,proto3,enum=xref.Status_STATUS" json:"status,omitempty"`
+	AllocatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=allocated_at,json=allocatedAt,proto3" json:"allocated_at,omitempty"`
+}
+
+func (x *MagicNumberDetail) Reset() {
+	*x = MagicNumberDetail{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MagicNumberDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MagicNumberDetail) ProtoMessage() {}
+
+func (x *MagicNumberDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MagicNumberDetail.ProtoReflect.Descriptor instead.
+func (*MagicNumberDetail) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MagicNumberDetail) GetLastfour() string {
+	if x != nil {
+		return x.Lastfour
+	}
+	return ""
+}
+
+func (x *MagicNumberDetail) GetToken() *XREF {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *MagicNumberDetail) GetStatus() Status_STATUS {
+	if x != nil {
+		return x.Status
+	}
+	return Status_AVAILABLE
+}
+
+func (x *MagicNumberDetail) GetAllocatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AllocatedAt
+	}
+	return nil
+}
+
 type MagicNumberSummary struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -328,7 +558,7 @@ type MagicNumberSummary struct {
 func (x *MagicNumberSummary) Reset() {
 	*x = MagicNumberSummary{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_xref_xref_proto_msgTypes[5]
+		mi := &file_xref_xref_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -341,7 +571,7 @@ func (x *MagicNumberSummary) String() string {
 func (*MagicNumberSummary) ProtoMessage() {}
 
 func (x *MagicNumberSummary) ProtoReflect() protoreflect.Message {
-	mi := &file_xref_xref_proto_msgTypes[5]
+	mi := &file_xref_xref_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -354,7 +584,7 @@ func (x *MagicNumberSummary) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MagicNumberSummary.ProtoReflect.Descriptor instead.
 func (*MagicNumberSummary) Descriptor() ([]byte, []int) {
-	return file_xref_xref_proto_rawDescGZIP(), []int{5}
+	return file_xref_xref_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *MagicNumberSummary) GetTotal() uint64 {
@@ -364,31 +594,79 @@ func (x *MagicNumberSummary) GetTotal() uint64 {
 	return 0
 }
 
-type Status struct {
+type PoolStatsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+}
 
-	Status Status_STATUS `protobuf:"varint,1,opt,name=status,proto3,enum=xref.Status_STATUS" json:"status,omitempty"`
+func (x *PoolStatsRequest) Reset() {
+	*x = PoolStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *Status) Reset() {
-	*x = Status{}
+func (x *PoolStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolStatsRequest) ProtoMessage() {}
+
+func (x *PoolStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolStatsRequest.ProtoReflect.Descriptor instead.
+func (*PoolStatsRequest) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{7}
+}
+
+type PoolStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// magic numbers by lifecycle state
+	Available        uint64            `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
+	Unavailable      uint64            `protobuf:"varint,2,opt,name=unavailable,proto3" json:"unavailable,omitempty"`
+	Xrefs            uint64            `protobuf:"varint,3,opt,name=xrefs,proto3" json:"xrefs,omitempty"`
+	TotalAllocations uint64            `protobuf:"varint,4,opt,name=total_allocations,json=totalAllocations,proto3" json:"total_allocations,omitempty"`
+	AllocationRates  []*AllocationRate `protobuf:"bytes,5,rep,name=allocation_rates,json=allocationRates,proto3" json:"allocation_rates,omitempty"`
+	// time until no magic numbers are available at the 15 minute rate,
+	// unset when nothing was allocated in that window
+	EstimatedTimeToExhaustion *durationpb.Duration `protobuf:"bytes,6,opt,name=estimated_time_to_exhaustion,json=estimatedTimeToExhaustion,proto3" json:"estimated_time_to_exhaustion,omitempty"`
+	// magic numbers of deleted xrefs, never reallocated
+	Retired uint64 `protobuf:"varint,7,opt,name=retired,proto3" json:"retired,omitempty"`
+}
+
+func (x *PoolStats) Reset() {
+	*x = PoolStats{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_xref_xref_proto_msgTypes[6]
+		mi := &file_xref_xref_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Status) String() string {
+func (x *PoolStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Status) ProtoMessage() {}
+func (*PoolStats) ProtoMessage() {}
 
-func (x *Status) ProtoReflect() protoreflect.Message {
-	mi := &file_xref_xref_proto_msgTypes[6]
+func (x *PoolStats) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -399,143 +677,765 @@ func (x *Status) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Status.ProtoReflect.Descriptor instead.
-func (*Status) Descriptor() ([]byte, []int) {
-	return file_xref_xref_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use PoolStats.ProtoReflect.Descriptor instead.
+func (*PoolStats) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *Status) GetStatus() Status_STATUS {
+func (x *PoolStats) GetAvailable() uint64 {
 	if x != nil {
-		return x.Status
+		return x.Available
 	}
-	return Status_AVAILABLE
+	return 0
 }
 
-var File_xref_xref_proto protoreflect.FileDescriptor
+func (x *PoolStats) GetUnavailable() uint64 {
+	if x != nil {
+		return x.Unavailable
+	}
+	return 0
+}
 
-var file_xref_xref_proto_rawDesc = []byte{
-	0x0a, 0x0f, 0x78, 0x72, 0x65, 0x66, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x12, 0x04, 0x78, 0x72, 0x65, 0x66, 0x22, 0x29, 0x0a, 0x0b, 0x58, 0x72, 0x65, 0x66, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f,
-	0x75, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f,
-	0x75, 0x72, 0x22, 0x30, 0x0a, 0x0c, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x12, 0x20, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x0a, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x52, 0x45, 0x46, 0x52, 0x05, 0x74,
-	0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x1c, 0x0a, 0x04, 0x58, 0x52, 0x45, 0x46, 0x12, 0x14, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x22, 0x72, 0x0a, 0x0b, 0x58, 0x72, 0x65, 0x66, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
-	0x79, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6e, 0x65, 0x77, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4e, 0x65, 0x77, 0x12, 0x23,
-	0x0a, 0x0d, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x55, 0x70, 0x64, 0x61,
-	0x74, 0x65, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x5f, 0x74,
-	0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x65, 0x6c, 0x61, 0x70, 0x73,
-	0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x23, 0x0a, 0x0b, 0x4d, 0x61, 0x67, 0x69, 0x63, 0x4e,
-	0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x2a, 0x0a, 0x12, 0x4d,
-	0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
-	0x79, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
-	0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x22, 0x5f, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75,
-	0x73, 0x12, 0x2b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0e, 0x32, 0x13, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e,
-	0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x28,
-	0x0a, 0x06, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x12, 0x0d, 0x0a, 0x09, 0x41, 0x56, 0x41, 0x49,
-	0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41,
-	0x49, 0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01, 0x32, 0xab, 0x02, 0x0a, 0x0b, 0x58, 0x72, 0x65,
-	0x66, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x32, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x58,
-	0x72, 0x65, 0x66, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52,
-	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72,
-	0x65, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x15,
-	0x47, 0x65, 0x74, 0x4d, 0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x53, 0x75,
-	0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x0c, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x1a, 0x18, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x4d, 0x61, 0x67, 0x69, 0x63,
-	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x22, 0x00, 0x12,
-	0x34, 0x0a, 0x08, 0x41, 0x64, 0x64, 0x58, 0x72, 0x65, 0x66, 0x73, 0x12, 0x11, 0x2e, 0x78, 0x72,
-	0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11,
-	0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
-	0x79, 0x22, 0x00, 0x28, 0x01, 0x12, 0x36, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x67, 0x69,
-	0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x0c, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e,
-	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x1a, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x4d, 0x61,
-	0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x00, 0x30, 0x01, 0x12, 0x37, 0x0a,
-	0x08, 0x47, 0x65, 0x74, 0x58, 0x72, 0x65, 0x66, 0x73, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66,
-	0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78,
-	0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
-	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x63, 0x67, 0x65, 0x6f, 0x72, 0x67, 0x69, 0x61, 0x64, 0x65, 0x73,
-	0x32, 0x37, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x64, 0x65, 0x6d, 0x6f, 0x2f, 0x70, 0x6b, 0x67,
-	0x2f, 0x78, 0x72, 0x65, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+func (x *PoolStats) GetXrefs() uint64 {
+	if x != nil {
+		return x.Xrefs
+	}
+	return 0
 }
 
-var (
-	file_xref_xref_proto_rawDescOnce sync.Once
-	file_xref_xref_proto_rawDescData = file_xref_xref_proto_rawDesc
-)
+func (x *PoolStats) GetTotalAllocations() uint64 {
+	if x != nil {
+		return x.TotalAllocations
+	}
+	return 0
+}
 
-func file_xref_xref_proto_rawDescGZIP() []byte {
-	file_xref_xref_proto_rawDescOnce.Do(func() {
-		file_xref_xref_proto_rawDescData = protoimpl.X.CompressGZIP(file_xref_xref_proto_rawDescData)
-	})
-	return file_xref_xref_proto_rawDescData
+func (x *PoolStats) GetAllocationRates() []*AllocationRate {
+	if x != nil {
+		return x.AllocationRates
+	}
+	return nil
 }
 
-var file_xref_xref_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_xref_xref_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
-var file_xref_xref_proto_goTypes = []interface{}{
-	(Status_STATUS)(0),         // 0: xref.Status.STATUS
-	(*XrefRequest)(nil),        // 1: xref.XrefRequest
-	(*XrefResponse)(nil),       // 2: xref.XrefResponse
-	(*XREF)(nil),               // 3: xref.XREF
-	(*XrefSummary)(nil),        // 4: xref.XrefSummary
-	(*MagicNumber)(nil),        // 5: xref.MagicNumber
-	(*MagicNumberSummary)(nil), // 6: xref.MagicNumberSummary
-	(*Status)(nil),             // 7: xref.Status
+func (x *PoolStats) GetEstimatedTimeToExhaustion() *durationpb.Duration {
+	if x != nil {
+		return x.EstimatedTimeToExhaustion
+	}
+	return nil
 }
-var file_xref_xref_proto_depIdxs = []int32{
-	3, // 0: xref.XrefResponse.token:type_name -> xref.XREF
-	0, // 1: xref.Status.status:type_name -> xref.Status.STATUS
-	1, // 2: xref.XrefService.GetXref:input_type -> xref.XrefRequest
-	7, // 3: xref.XrefService.GetMagicNumberSummary:input_type -> xref.Status
-	1, // 4: xref.XrefService.AddXrefs:input_type -> xref.XrefRequest
-	7, // 5: xref.XrefService.GetMagicNumbers:input_type -> xref.Status
-	1, // 6: xref.XrefService.GetXrefs:input_type -> xref.XrefRequest
-	2, // 7: xref.XrefService.GetXref:output_type -> xref.XrefResponse
-	6, // 8: xref.XrefService.GetMagicNumberSummary:output_type -> xref.MagicNumberSummary
-	4, // 9: xref.XrefService.AddXrefs:output_type -> xref.XrefSummary
-	5, // 10: xref.XrefService.GetMagicNumbers:output_type -> xref.MagicNumber
-	2, // 11: xref.XrefService.GetXrefs:output_type -> xref.XrefResponse
-	7, // [7:12] is the sub-list for method output_type
-	2, // [2:7] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+
+func (x *PoolStats) GetRetired() uint64 {
+	if x != nil {
+		return x.Retired
+	}
+	return 0
 }
 
-func init() { file_xref_xref_proto_init() }
-func file_xref_xref_proto_init() {
-	if File_xref_xref_proto != nil {
-		return
+type AllocationRate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	WindowMinutes uint32  `protobuf:"varint,1,opt,name=window_minutes,json=windowMinutes,proto3" json:"window_minutes,omitempty"`
+	PerMinute     float64 `protobuf:"fixed64,2,opt,name=per_minute,json=perMinute,proto3" json:"per_minute,omitempty"`
+}
+
+func (x *AllocationRate) Reset() {
+	*x = AllocationRate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_xref_xref_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*XrefRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_xref_xref_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*XrefResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
+}
+
+func (x *AllocationRate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AllocationRate) ProtoMessage() {}
+
+func (x *AllocationRate) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AllocationRate.ProtoReflect.Descriptor instead.
+func (*AllocationRate) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AllocationRate) GetWindowMinutes() uint32 {
+	if x != nil {
+		return x.WindowMinutes
+	}
+	return 0
+}
+
+func (x *AllocationRate) GetPerMinute() float64 {
+	if x != nil {
+		return x.PerMinute
+	}
+	return 0
+}
+
+type PoolLevelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// only stream updates where the level changed
+	ChangesOnly bool `protobuf:"varint,1,opt,name=changes_only,json=changesOnly,proto3" json:"changes_only,omitempty"`
+}
+
+func (x *PoolLevelRequest) Reset() {
+	*x = PoolLevelRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PoolLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolLevelRequest) ProtoMessage() {}
+
+func (x *PoolLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolLevelRequest.ProtoReflect.Descriptor instead.
+func (*PoolLevelRequest) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PoolLevelRequest) GetChangesOnly() bool {
+	if x != nil {
+		return x.ChangesOnly
+	}
+	return false
+}
+
+type PoolLevel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Available uint64          `protobuf:"varint,1,opt,name=available,proto3" json:"available,omitempty"`
+	Level     PoolLevel_LEVEL `protobuf:"varint,2,opt,name=level,proto3,enum=xref.PoolLevel_LEVEL" json:"level,omitempty"`
+	// level of the update before this one, OK for the first
+	PreviousLevel PoolLevel_LEVEL        `protobuf:"varint,3,opt,name=previous_level,json=previousLevel,proto3,enum=xref.PoolLevel_LEVEL" json:"previous_level,omitempty"`
+	ObservedAt    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=observed_at,json=observedAt,proto3" json:"observed_at,omitempty"`
+}
+
+func (x *PoolLevel) Reset() {
+	*x = PoolLevel{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PoolLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolLevel) ProtoMessage() {}
+
+func (x *PoolLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolLevel.ProtoReflect.Descriptor instead.
+func (*PoolLevel) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *PoolLevel) GetAvailable() uint64 {
+	if x != nil {
+		return x.Available
+	}
+	return 0
+}
+
+func (x *PoolLevel) GetLevel() PoolLevel_LEVEL {
+	if x != nil {
+		return x.Level
+	}
+	return PoolLevel_OK
+}
+
+func (x *PoolLevel) GetPreviousLevel() PoolLevel_LEVEL {
+	if x != nil {
+		return x.PreviousLevel
+	}
+	return PoolLevel_OK
+}
+
+func (x *PoolLevel) GetObservedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ObservedAt
+	}
+	return nil
+}
+
+type WatchXrefsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// resume after this event id, empty streams new events only
+	LastEventId string `protobuf:"bytes,1,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+}
+
+func (x *WatchXrefsRequest) Reset() {
+	*x = WatchXrefsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WatchXrefsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchXrefsRequest) ProtoMessage() {}
+
+func (x *WatchXrefsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchXrefsRequest.ProtoReflect.Descriptor instead.
+func (*WatchXrefsRequest) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *WatchXrefsRequest) GetLastEventId() string {
+	if x != nil {
+		return x.LastEventId
+	}
+	return ""
+}
+
+type XrefEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type        XrefEvent_TYPE         `protobuf:"varint,2,opt,name=type,proto3,enum=xref.XrefEvent_TYPE" json:"type,omitempty"`
+	Lastfour    string                 `protobuf:"bytes,3,opt,name=lastfour,proto3" json:"lastfour,omitempty"`
+	Token       *XREF                  `protobuf:"bytes,4,opt,name=token,proto3" json:"token,omitempty"`
+	MagicNumber string                 `protobuf:"bytes,5,opt,name=magic_number,json=magicNumber,proto3" json:"magic_number,omitempty"`
+	OccurredAt  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+}
+
+func (x *XrefEvent) Reset() {
+	*x = XrefEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *XrefEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*XrefEvent) ProtoMessage() {}
+
+func (x *XrefEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use XrefEvent.ProtoReflect.Descriptor instead.
+func (*XrefEvent) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *XrefEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *XrefEvent) GetType() XrefEvent_TYPE {
+	if x != nil {
+		return x.Type
+	}
+	return XrefEvent_CREATED
+}
+
+func (x *XrefEvent) GetLastfour() string {
+	if x != nil {
+		return x.Lastfour
+	}
+	return ""
+}
+
+func (x *XrefEvent) GetToken() *XREF {
+	if x != nil {
+		return x.Token
+	}
+	return nil
+}
+
+func (x *XrefEvent) GetMagicNumber() string {
+	if x != nil {
+		return x.MagicNumber
+	}
+	return ""
+}
+
+func (x *XrefEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+type Status struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status Status_STATUS `protobuf:"varint,1,opt,name=status,proto3,enum=xref.Status_STATUS" json:"status,omitempty"`
+	// max magic numbers to stream, 0 streams all
+	PageSize uint32 `protobuf:"varint,2,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token of the last magic number received
+	PageToken string `protobuf:"bytes,3,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// only magic numbers starting with prefix
+	Prefix string `protobuf:"bytes,4,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// include the xref mapping of each magic number
+	IncludeDetails bool `protobuf:"varint,5,opt,name=include_details,json=includeDetails,proto3" json:"include_details,omitempty"`
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_xref_xref_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_xref_xref_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_xref_xref_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *Status) GetStatus() Status_STATUS {
+	if x != nil {
+		return x.Status
+	}
+	return Status_AVAILABLE
+}
+
+func (x *Status) GetPageSize() uint32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *Status) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *Status) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *Status) GetIncludeDetails() bool {
+	if x != nil {
+		return x.IncludeDetails
+	}
+	return false
+}
+
+var File_xref_xref_proto protoreflect.FileDescriptor
+
+var file_xref_xref_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x78, 0x72, 0x65, 0x66, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x04, 0x78, 0x72, 0x65, 0x66, 0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f,
+	0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x6e, 0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x72,
+	0x70, 0x63, 0x2f, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0x29, 0x0a, 0x0b, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a,
+	0x0a, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x22, 0x90, 0x01, 0x0a, 0x0c, 0x58,
+	0x72, 0x65, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x20, 0x0a, 0x05, 0x74,
+	0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x78, 0x72, 0x65,
+	0x66, 0x2e, 0x58, 0x52, 0x45, 0x46, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x1a, 0x0a,
+	0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x12, 0x28, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x72, 0x70, 0x63, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x22, 0x1c, 0x0a,
+	0x04, 0x58, 0x52, 0x45, 0x46, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x95, 0x01, 0x0a, 0x0b,
+	0x58, 0x72, 0x65, 0x66, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x6e, 0x65, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x4e, 0x65, 0x77, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x5f, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x12, 0x21, 0x0a,
+	0x0c, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0b, 0x65, 0x6c, 0x61, 0x70, 0x73, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65,
+	0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x46, 0x61, 0x69,
+	0x6c, 0x65, 0x64, 0x22, 0x73, 0x0a, 0x0b, 0x4d, 0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62,
+	0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61,
+	0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x2f, 0x0a, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69,
+	0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x4d,
+	0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c,
+	0x52, 0x06, 0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x22, 0xbd, 0x01, 0x0a, 0x11, 0x4d, 0x61, 0x67,
+	0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x1a,
+	0x0a, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x12, 0x20, 0x0a, 0x05, 0x74, 0x6f,
+	0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x78, 0x72, 0x65, 0x66,
+	0x2e, 0x58, 0x52, 0x45, 0x46, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x2b, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x78,
+	0x72, 0x65, 0x66, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x3d, 0x0a, 0x0c, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x2a, 0x0a, 0x12, 0x4d, 0x61, 0x67, 0x69,
+	0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x74,
+	0x6f, 0x74, 0x61, 0x6c, 0x22, 0x12, 0x0a, 0x10, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xc5, 0x02, 0x0a, 0x09, 0x50, 0x6f, 0x6f,
+	0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61,
+	0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c,
+	0x61, 0x62, 0x6c, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x75, 0x6e, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61,
+	0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x75, 0x6e, 0x61, 0x76, 0x61,
+	0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x78, 0x72, 0x65, 0x66, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x78, 0x72, 0x65, 0x66, 0x73, 0x12, 0x2b, 0x0a, 0x11,
+	0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x6c,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3f, 0x0a, 0x10, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x72, 0x61, 0x74, 0x65, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61, 0x74, 0x65, 0x52, 0x0f, 0x61, 0x6c, 0x6c, 0x6f, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61, 0x74, 0x65, 0x73, 0x12, 0x5a, 0x0a, 0x1c, 0x65, 0x73,
+	0x74, 0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x74, 0x6f, 0x5f,
+	0x65, 0x78, 0x68, 0x61, 0x75, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x19, 0x65, 0x73, 0x74,
+	0x69, 0x6d, 0x61, 0x74, 0x65, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x54, 0x6f, 0x45, 0x78, 0x68, 0x61,
+	0x75, 0x73, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x72, 0x65, 0x74, 0x69, 0x72, 0x65,
+	0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x72, 0x65, 0x74, 0x69, 0x72, 0x65, 0x64,
+	0x22, 0x56, 0x0a, 0x0e, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x61,
+	0x74, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6d, 0x69, 0x6e,
+	0x75, 0x74, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x77, 0x69, 0x6e, 0x64,
+	0x6f, 0x77, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x65, 0x72,
+	0x5f, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x70,
+	0x65, 0x72, 0x4d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x22, 0x35, 0x0a, 0x10, 0x50, 0x6f, 0x6f, 0x6c,
+	0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c,
+	0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x5f, 0x6f, 0x6e, 0x6c, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x4f, 0x6e, 0x6c, 0x79, 0x22,
+	0x88, 0x02, 0x0a, 0x09, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x1c, 0x0a,
+	0x09, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x09, 0x61, 0x76, 0x61, 0x69, 0x6c, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x2b, 0x0a, 0x05, 0x6c,
+	0x65, 0x76, 0x65, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x78, 0x72, 0x65,
+	0x66, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x2e, 0x4c, 0x45, 0x56, 0x45,
+	0x4c, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x3c, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x76,
+	0x69, 0x6f, 0x75, 0x73, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x15, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x2e, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x52, 0x0d, 0x70, 0x72, 0x65, 0x76, 0x69, 0x6f, 0x75,
+	0x73, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x3b, 0x0a, 0x0b, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76,
+	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6f, 0x62, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x64, 0x41, 0x74, 0x22, 0x35, 0x0a, 0x05, 0x4c, 0x45, 0x56, 0x45, 0x4c, 0x12, 0x06, 0x0a, 0x02,
+	0x4f, 0x4b, 0x10, 0x00, 0x12, 0x07, 0x0a, 0x03, 0x4c, 0x4f, 0x57, 0x10, 0x01, 0x12, 0x0c, 0x0a,
+	0x08, 0x43, 0x52, 0x49, 0x54, 0x49, 0x43, 0x41, 0x4c, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x45,
+	0x58, 0x48, 0x41, 0x55, 0x53, 0x54, 0x45, 0x44, 0x10, 0x03, 0x22, 0x37, 0x0a, 0x11, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x58, 0x72, 0x65, 0x66, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x22, 0x0a, 0x0d, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x49, 0x64, 0x22, 0x92, 0x02, 0x0a, 0x09, 0x58, 0x72, 0x65, 0x66, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x28, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x14, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x2e, 0x54, 0x59, 0x50, 0x45, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c,
+	0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c,
+	0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x12, 0x20, 0x0a, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x52,
+	0x45, 0x46, 0x52, 0x05, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x61, 0x67,
+	0x69, 0x63, 0x5f, 0x6e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0b, 0x6d, 0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x12, 0x3b, 0x0a, 0x0b,
+	0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6f,
+	0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x64, 0x41, 0x74, 0x22, 0x2d, 0x0a, 0x04, 0x54, 0x59, 0x50,
+	0x45, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x52, 0x45, 0x41, 0x54, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b,
+	0x0a, 0x07, 0x52, 0x4f, 0x54, 0x41, 0x54, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x44,
+	0x45, 0x4c, 0x45, 0x54, 0x45, 0x44, 0x10, 0x02, 0x22, 0xdc, 0x01, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x2b, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x2e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x12, 0x16, 0x0a, 0x06,
+	0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72,
+	0x65, 0x66, 0x69, 0x78, 0x12, 0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f,
+	0x64, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x69,
+	0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x22, 0x28, 0x0a,
+	0x06, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x12, 0x0d, 0x0a, 0x09, 0x41, 0x56, 0x41, 0x49, 0x4c,
+	0x41, 0x42, 0x4c, 0x45, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x41, 0x56, 0x41, 0x49,
+	0x4c, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x01, 0x32, 0xa7, 0x07, 0x0a, 0x0b, 0x58, 0x72, 0x65, 0x66,
+	0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x4e, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x58, 0x72,
+	0x65, 0x66, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65,
+	0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x16, 0x22, 0x14, 0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f, 0x7b, 0x6c, 0x61,
+	0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x7d, 0x12, 0x61, 0x0a, 0x15, 0x47, 0x65, 0x74, 0x4d, 0x61,
+	0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79,
+	0x12, 0x0c, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x1a, 0x18,
+	0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x4d, 0x61, 0x67, 0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x22, 0x20, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x1a,
+	0x12, 0x18, 0x2f, 0x76, 0x31, 0x2f, 0x6d, 0x61, 0x67, 0x69, 0x63, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x73, 0x2f, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x48, 0x0a, 0x08, 0x41, 0x64,
+	0x64, 0x58, 0x72, 0x65, 0x66, 0x73, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72,
+	0x65, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66,
+	0x2e, 0x58, 0x72, 0x65, 0x66, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x22, 0x14, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x0e, 0x22, 0x09, 0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x3a,
+	0x01, 0x2a, 0x28, 0x01, 0x12, 0x4e, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4d, 0x61, 0x67, 0x69, 0x63,
+	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x73, 0x12, 0x0c, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x1a, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x4d, 0x61, 0x67,
+	0x69, 0x63, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12,
+	0x12, 0x10, 0x2f, 0x76, 0x31, 0x2f, 0x6d, 0x61, 0x67, 0x69, 0x63, 0x6e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x73, 0x30, 0x01, 0x12, 0x51, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x58, 0x72, 0x65, 0x66, 0x73,
+	0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1a, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x14, 0x22,
+	0x0f, 0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f, 0x62, 0x61, 0x74, 0x63, 0x68,
+	0x3a, 0x01, 0x2a, 0x28, 0x01, 0x30, 0x01, 0x12, 0x4f, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x50, 0x6f,
+	0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x16, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x50,
+	0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x0f, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x6f,
+	0x6f, 0x6c, 0x2f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x12, 0x53, 0x0a, 0x0e, 0x57, 0x61, 0x74, 0x63,
+	0x68, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x16, 0x2e, 0x78, 0x72, 0x65,
+	0x66, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x4c, 0x65,
+	0x76, 0x65, 0x6c, 0x22, 0x16, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x10, 0x12, 0x0e, 0x2f, 0x76, 0x31,
+	0x2f, 0x70, 0x6f, 0x6f, 0x6c, 0x2f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x30, 0x01, 0x12, 0x52, 0x0a,
+	0x0a, 0x57, 0x61, 0x74, 0x63, 0x68, 0x58, 0x72, 0x65, 0x66, 0x73, 0x12, 0x17, 0x2e, 0x78, 0x72,
+	0x65, 0x66, 0x2e, 0x57, 0x61, 0x74, 0x63, 0x68, 0x58, 0x72, 0x65, 0x66, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x18, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x12, 0x12, 0x10, 0x2f,
+	0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x30,
+	0x01, 0x12, 0x51, 0x0a, 0x0a, 0x4c, 0x6f, 0x6f, 0x6b, 0x75, 0x70, 0x58, 0x72, 0x65, 0x66, 0x12,
+	0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x16, 0x12, 0x14,
+	0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f, 0x7b, 0x6c, 0x61, 0x73, 0x74, 0x66,
+	0x6f, 0x75, 0x72, 0x7d, 0x12, 0x51, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x58, 0x72,
+	0x65, 0x66, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65, 0x66, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65,
+	0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x1c, 0x82, 0xd3, 0xe4, 0x93, 0x02,
+	0x16, 0x2a, 0x14, 0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f, 0x7b, 0x6c, 0x61,
+	0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x7d, 0x12, 0x58, 0x0a, 0x0a, 0x52, 0x6f, 0x74, 0x61, 0x74,
+	0x65, 0x58, 0x72, 0x65, 0x66, 0x12, 0x11, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e, 0x58, 0x72, 0x65,
+	0x66, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x12, 0x2e, 0x78, 0x72, 0x65, 0x66, 0x2e,
+	0x58, 0x72, 0x65, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x23, 0x82, 0xd3,
+	0xe4, 0x93, 0x02, 0x1d, 0x22, 0x1b, 0x2f, 0x76, 0x31, 0x2f, 0x78, 0x72, 0x65, 0x66, 0x73, 0x2f,
+	0x7b, 0x6c, 0x61, 0x73, 0x74, 0x66, 0x6f, 0x75, 0x72, 0x7d, 0x2f, 0x72, 0x6f, 0x74, 0x61, 0x74,
+	0x65, 0x42, 0x2d, 0x5a, 0x2b, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x63, 0x67, 0x65, 0x6f, 0x72, 0x67, 0x69, 0x61, 0x64, 0x65, 0x73, 0x32, 0x37, 0x2f, 0x67, 0x72,
+	0x70, 0x63, 0x2d, 0x64, 0x65, 0x6d, 0x6f, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x78, 0x72, 0x65, 0x66,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_xref_xref_proto_rawDescOnce sync.Once
+	file_xref_xref_proto_rawDescData = file_xref_xref_proto_rawDesc
+)
+
+func file_xref_xref_proto_rawDescGZIP() []byte {
+	file_xref_xref_proto_rawDescOnce.Do(func() {
+		file_xref_xref_proto_rawDescData = protoimpl.X.CompressGZIP(file_xref_xref_proto_rawDescData)
+	})
+	return file_xref_xref_proto_rawDescData
+}
+
+var file_xref_xref_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_xref_xref_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_xref_xref_proto_goTypes = []interface{}{
+	(PoolLevel_LEVEL)(0),          // 0: xref.PoolLevel.LEVEL
+	(XrefEvent_TYPE)(0),           // 1: xref.XrefEvent.TYPE
+	(Status_STATUS)(0),            // 2: xref.Status.STATUS
+	(*XrefRequest)(nil),           // 3: xref.XrefRequest
+	(*XrefResponse)(nil),          // 4: xref.XrefResponse
+	(*XREF)(nil),                  // 5: xref.XREF
+	(*XrefSummary)(nil),           // 6: xref.XrefSummary
+	(*MagicNumber)(nil),           // 7: xref.MagicNumber
+	(*MagicNumberDetail)(nil),     // 8: xref.MagicNumberDetail
+	(*MagicNumberSummary)(nil),    // 9: xref.MagicNumberSummary
+	(*PoolStatsRequest)(nil),      // 10: xref.PoolStatsRequest
+	(*PoolStats)(nil),             // 11: xref.PoolStats
+	(*AllocationRate)(nil),        // 12: xref.AllocationRate
+	(*PoolLevelRequest)(nil),      // 13: xref.PoolLevelRequest
+	(*PoolLevel)(nil),             // 14: xref.PoolLevel
+	(*WatchXrefsRequest)(nil),     // 15: xref.WatchXrefsRequest
+	(*XrefEvent)(nil),             // 16: xref.XrefEvent
+	(*Status)(nil),                // 17: xref.Status
+	(*status.Status)(nil),         // 18: google.rpc.Status
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 20: google.protobuf.Duration
+}
+var file_xref_xref_proto_depIdxs = []int32{
+	5,  // 0: xref.XrefResponse.token:type_name -> xref.XREF
+	18, // 1: xref.XrefResponse.error:type_name -> google.rpc.Status
+	8,  // 2: xref.MagicNumber.detail:type_name -> xref.MagicNumberDetail
+	5,  // 3: xref.MagicNumberDetail.token:type_name -> xref.XREF
+	2,  // 4: xref.MagicNumberDetail.status:type_name -> xref.Status.STATUS
+	19, // 5: xref.MagicNumberDetail.allocated_at:type_name -> google.protobuf.Timestamp
+	12, // 6: xref.PoolStats.allocation_rates:type_name -> xref.AllocationRate
+	20, // 7: xref.PoolStats.estimated_time_to_exhaustion:type_name -> google.protobuf.Duration
+	0,  // 8: xref.PoolLevel.level:type_name -> xref.PoolLevel.LEVEL
+	0,  // 9: xref.PoolLevel.previous_level:type_name -> xref.PoolLevel.LEVEL
+	19, // 10: xref.PoolLevel.observed_at:type_name -> google.protobuf.Timestamp
+	1,  // 11: xref.XrefEvent.type:type_name -> xref.XrefEvent.TYPE
+	5,  // 12: xref.XrefEvent.token:type_name -> xref.XREF
+	19, // 13: xref.XrefEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	2,  // 14: xref.Status.status:type_name -> xref.Status.STATUS
+	3,  // 15: xref.XrefService.GetXref:input_type -> xref.XrefRequest
+	17, // 16: xref.XrefService.GetMagicNumberSummary:input_type -> xref.Status
+	3,  // 17: xref.XrefService.AddXrefs:input_type -> xref.XrefRequest
+	17, // 18: xref.XrefService.GetMagicNumbers:input_type -> xref.Status
+	3,  // 19: xref.XrefService.GetXrefs:input_type -> xref.XrefRequest
+	10, // 20: xref.XrefService.GetPoolStats:input_type -> xref.PoolStatsRequest
+	13, // 21: xref.XrefService.WatchPoolLevel:input_type -> xref.PoolLevelRequest
+	15, // 22: xref.XrefService.WatchXrefs:input_type -> xref.WatchXrefsRequest
+	3,  // 23: xref.XrefService.LookupXref:input_type -> xref.XrefRequest
+	3,  // 24: xref.XrefService.DeleteXref:input_type -> xref.XrefRequest
+	3,  // 25: xref.XrefService.RotateXref:input_type -> xref.XrefRequest
+	4,  // 26: xref.XrefService.GetXref:output_type -> xref.XrefResponse
+	9,  // 27: xref.XrefService.GetMagicNumberSummary:output_type -> xref.MagicNumberSummary
+	6,  // 28: xref.XrefService.AddXrefs:output_type -> xref.XrefSummary
+	7,  // 29: xref.XrefService.GetMagicNumbers:output_type -> xref.MagicNumber
+	4,  // 30: xref.XrefService.GetXrefs:output_type -> xref.XrefResponse
+	11, // 31: xref.XrefService.GetPoolStats:output_type -> xref.PoolStats
+	14, // 32: xref.XrefService.WatchPoolLevel:output_type -> xref.PoolLevel
+	16, // 33: xref.XrefService.WatchXrefs:output_type -> xref.XrefEvent
+	4,  // 34: xref.XrefService.LookupXref:output_type -> xref.XrefResponse
+	4,  // 35: xref.XrefService.DeleteXref:output_type -> xref.XrefResponse
+	4,  // 36: xref.XrefService.RotateXref:output_type -> xref.XrefResponse
+	26, // [26:37] is the sub-list for method output_type
+	15, // [15:26] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_xref_xref_proto_init() }
+func file_xref_xref_proto_init() {
+	if File_xref_xref_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_xref_xref_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*XrefRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*XrefResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
 			default:
 				return nil
 			}
@@ -577,7 +1477,7 @@ func file_xref_xref_proto_init() {
 			}
 		}
 		file_xref_xref_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*MagicNumberSummary); i {
+			switch v := v.(*MagicNumberDetail); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -589,6 +1489,102 @@ func file_xref_xref_proto_init() {
 			}
 		}
 		file_xref_xref_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MagicNumberSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PoolStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PoolStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AllocationRate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PoolLevelRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PoolLevel); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WatchXrefsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*XrefEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_xref_xref_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Status); i {
 			case 0:
 				return &v.state
@@ -606,8 +1602,8 @@ func file_xref_xref_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_xref_xref_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   7,
+			NumEnums:      3,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
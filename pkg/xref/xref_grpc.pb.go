@@ -27,6 +27,12 @@ type XrefServiceClient interface {
 	AddXrefs(ctx context.Context, opts ...grpc.CallOption) (XrefService_AddXrefsClient, error)
 	GetMagicNumbers(ctx context.Context, in *Status, opts ...grpc.CallOption) (XrefService_GetMagicNumbersClient, error)
 	GetXrefs(ctx context.Context, opts ...grpc.CallOption) (XrefService_GetXrefsClient, error)
+	GetPoolStats(ctx context.Context, in *PoolStatsRequest, opts ...grpc.CallOption) (*PoolStats, error)
+	WatchPoolLevel(ctx context.Context, in *PoolLevelRequest, opts ...grpc.CallOption) (XrefService_WatchPoolLevelClient, error)
+	WatchXrefs(ctx context.Context, in *WatchXrefsRequest, opts ...grpc.CallOption) (XrefService_WatchXrefsClient, error)
+	LookupXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error)
+	DeleteXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error)
+	RotateXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error)
 }
 
 type xrefServiceClient struct {
@@ -152,6 +158,106 @@ func (x *xrefServiceGetXrefsClient) Recv() (*XrefResponse, error) {
 	return m, nil
 }
 
+func (c *xrefServiceClient) GetPoolStats(ctx context.Context, in *PoolStatsRequest, opts ...grpc.CallOption) (*PoolStats, error) {
+	out := new(PoolStats)
+	err := c.cc.Invoke(ctx, "/xref.XrefService/GetPoolStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *xrefServiceClient) WatchPoolLevel(ctx context.Context, in *PoolLevelRequest, opts ...grpc.CallOption) (XrefService_WatchPoolLevelClient, error) {
+	stream, err := c.cc.NewStream(ctx, &XrefService_ServiceDesc.Streams[3], "/xref.XrefService/WatchPoolLevel", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &xrefServiceWatchPoolLevelClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type XrefService_WatchPoolLevelClient interface {
+	Recv() (*PoolLevel, error)
+	grpc.ClientStream
+}
+
+type xrefServiceWatchPoolLevelClient struct {
+	grpc.ClientStream
+}
+
+func (x *xrefServiceWatchPoolLevelClient) Recv() (*PoolLevel, error) {
+	m := new(PoolLevel)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *xrefServiceClient) WatchXrefs(ctx context.Context, in *WatchXrefsRequest, opts ...grpc.CallOption) (XrefService_WatchXrefsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &XrefService_ServiceDesc.Streams[4], "/xref.XrefService/WatchXrefs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &xrefServiceWatchXrefsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type XrefService_WatchXrefsClient interface {
+	Recv() (*XrefEvent, error)
+	grpc.ClientStream
+}
+
+type xrefServiceWatchXrefsClient struct {
+	grpc.ClientStream
+}
+
+func (x *xrefServiceWatchXrefsClient) Recv() (*XrefEvent, error) {
+	m := new(XrefEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *xrefServiceClient) LookupXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error) {
+	out := new(XrefResponse)
+	err := c.cc.Invoke(ctx, "/xref.XrefService/LookupXref", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *xrefServiceClient) DeleteXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error) {
+	out := new(XrefResponse)
+	err := c.cc.Invoke(ctx, "/xref.XrefService/DeleteXref", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *xrefServiceClient) RotateXref(ctx context.Context, in *XrefRequest, opts ...grpc.CallOption) (*XrefResponse, error) {
+	out := new(XrefResponse)
+	err := c.cc.Invoke(ctx, "/xref.XrefService/RotateXref", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // XrefServiceServer is the server API for XrefService service.
 // All implementations must embed UnimplementedXrefServiceServer
 // for forward compatibility
@@ -161,6 +267,12 @@ type XrefServiceServer interface {
 	AddXrefs(XrefService_AddXrefsServer) error
 	GetMagicNumbers(*Status, XrefService_GetMagicNumbersServer) error
 	GetXrefs(XrefService_GetXrefsServer) error
+	GetPoolStats(context.Context, *PoolStatsRequest) (*PoolStats, error)
+	WatchPoolLevel(*PoolLevelRequest, XrefService_WatchPoolLevelServer) error
+	WatchXrefs(*WatchXrefsRequest, XrefService_WatchXrefsServer) error
+	LookupXref(context.Context, *XrefRequest) (*XrefResponse, error)
+	DeleteXref(context.Context, *XrefRequest) (*XrefResponse, error)
+	RotateXref(context.Context, *XrefRequest) (*XrefResponse, error)
 	mustEmbedUnimplementedXrefServiceServer()
 }
 
@@ -183,6 +295,24 @@ func (UnimplementedXrefServiceServer) GetMagicNumbers(*Status, XrefService_GetMa
 func (UnimplementedXrefServiceServer) GetXrefs(XrefService_GetXrefsServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetXrefs not implemented")
 }
+func (UnimplementedXrefServiceServer) GetPoolStats(context.Context, *PoolStatsRequest) (*PoolStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoolStats not implemented")
+}
+func (UnimplementedXrefServiceServer) WatchPoolLevel(*PoolLevelRequest, XrefService_WatchPoolLevelServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPoolLevel not implemented")
+}
+func (UnimplementedXrefServiceServer) WatchXrefs(*WatchXrefsRequest, XrefService_WatchXrefsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchXrefs not implemented")
+}
+func (UnimplementedXrefServiceServer) LookupXref(context.Context, *XrefRequest) (*XrefResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupXref not implemented")
+}
+func (UnimplementedXrefServiceServer) DeleteXref(context.Context, *XrefRequest) (*XrefResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteXref not implemented")
+}
+func (UnimplementedXrefServiceServer) RotateXref(context.Context, *XrefRequest) (*XrefResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateXref not implemented")
+}
 func (UnimplementedXrefServiceServer) mustEmbedUnimplementedXrefServiceServer() {}
 
 // UnsafeXrefServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -305,6 +435,120 @@ func (x *xrefServiceGetXrefsServer) Recv() (*XrefRequest, error) {
 	return m, nil
 }
 
+func _XrefService_GetPoolStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PoolStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(XrefServiceServer).GetPoolStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xref.XrefService/GetPoolStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(XrefServiceServer).GetPoolStats(ctx, req.(*PoolStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _XrefService_WatchPoolLevel_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(PoolLevelRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(XrefServiceServer).WatchPoolLevel(m, &xrefServiceWatchPoolLevelServer{stream})
+}
+
+type XrefService_WatchPoolLevelServer interface {
+	Send(*PoolLevel) error
+	grpc.ServerStream
+}
+
+type xrefServiceWatchPoolLevelServer struct {
+	grpc.ServerStream
+}
+
+func (x *xrefServiceWatchPoolLevelServer) Send(m *PoolLevel) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _XrefService_WatchXrefs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchXrefsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(XrefServiceServer).WatchXrefs(m, &xrefServiceWatchXrefsServer{stream})
+}
+
+type XrefService_WatchXrefsServer interface {
+	Send(*XrefEvent) error
+	grpc.ServerStream
+}
+
+type xrefServiceWatchXrefsServer struct {
+	grpc.ServerStream
+}
+
+func (x *xrefServiceWatchXrefsServer) Send(m *XrefEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _XrefService_LookupXref_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(XrefRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(XrefServiceServer).LookupXref(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xref.XrefService/LookupXref",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(XrefServiceServer).LookupXref(ctx, req.(*XrefRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _XrefService_DeleteXref_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(XrefRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(XrefServiceServer).DeleteXref(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xref.XrefService/DeleteXref",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(XrefServiceServer).DeleteXref(ctx, req.(*XrefRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _XrefService_RotateXref_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(XrefRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(XrefServiceServer).RotateXref(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/xref.XrefService/RotateXref",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(XrefServiceServer).RotateXref(ctx, req.(*XrefRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // XrefService_ServiceDesc is the grpc.ServiceDesc for XrefService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -320,6 +564,22 @@ var XrefService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetMagicNumberSummary",
 			Handler:    _XrefService_GetMagicNumberSummary_Handler,
 		},
+		{
+			MethodName: "GetPoolStats",
+			Handler:    _XrefService_GetPoolStats_Handler,
+		},
+		{
+			MethodName: "LookupXref",
+			Handler:    _XrefService_LookupXref_Handler,
+		},
+		{
+			MethodName: "DeleteXref",
+			Handler:    _XrefService_DeleteXref_Handler,
+		},
+		{
+			MethodName: "RotateXref",
+			Handler:    _XrefService_RotateXref_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -338,6 +598,16 @@ var XrefService_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "WatchPoolLevel",
+			Handler:       _XrefService_WatchPoolLevel_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchXrefs",
+			Handler:       _XrefService_WatchXrefs_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "xref/xref.proto",
 }
@@ -3,36 +3,76 @@ package xref
 import (
 	"bufio"
 	"context"
-	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cgeorgiades27/grpc-demo/pkg/constants"
 	"github.com/cgeorgiades27/grpc-demo/pkg/models"
 	"github.com/go-redis/redis/v8"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const (
 	AVAILABLE   = "available"
 	UNAVAILABLE = "unavailable"
 	XMAP        = "xmap"
+	ALLOCATED   = "allocated"
+	RETIRED     = "retired"
 )
 
-func NewXrefService(ctx context.Context, rds *redis.Client) *xrefServer {
+// init modes of InitStore
+const (
+	INIT_RESET    = "reset"
+	INIT_IF_EMPTY = "if-empty"
+	INIT_SKIP     = "skip"
+)
+
+// NewXrefService returns the service storing xrefs in rds. Keys are
+// prefixed with the hash tag {keyTag} when set, which Redis Cluster needs.
+func NewXrefService(ctx context.Context, rds redis.UniversalClient, keyTag string) *xrefServer {
 	return &xrefServer{
 		UnimplementedXrefServiceServer: UnimplementedXrefServiceServer{},
 		ctx:                            ctx,
 		redis:                          rds,
+		keyTag:                         keyTag,
+		closing:                        make(chan struct{}),
+		events:                         &eventFeed{subs: make(map[chan redis.XMessage]struct{})},
 	}
 }
 
 type xrefServer struct {
 	UnimplementedXrefServiceServer
-	ctx   context.Context
-	redis *redis.Client
+	ctx     context.Context
+	redis   redis.UniversalClient
+	keyTag  string
+	monitor *poolMonitor
+	events  *eventFeed
+	health  *health.Server
+	// initialized is set once InitData completed
+	initialized int32
+	// closing is closed by Shutdown
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// key returns the redis key of name. With a key tag every key shares the
+// hash tag, keeping them in one cluster slot so transactions and multi key
+// commands work on Redis Cluster.
+func (x *xrefServer) key(name string) string {
+	if x.keyTag == "" {
+		return name
+	}
+	return "{" + x.keyTag + "}:" + name
 }
 
 func (x *xrefServer) InitData(path string) error {
@@ -44,27 +84,73 @@ func (x *xrefServer) InitData(path string) error {
 	defer f.Close()
 
 	// xref map
-	if err := x.redis.Del(x.ctx, XMAP).Err(); err != nil {
+	if err := x.redis.Del(x.ctx, x.key(XMAP)).Err(); err != nil {
 		return err
 	}
 
 	// magicnum list
-	if err := x.redis.Del(x.ctx, AVAILABLE).Err(); err != nil {
+	if err := x.redis.Del(x.ctx, x.key(AVAILABLE)).Err(); err != nil {
 		return err
 	}
 
 	// used magicnum list
-	if err := x.redis.Del(x.ctx, UNAVAILABLE).Err(); err != nil {
+	if err := x.redis.Del(x.ctx, x.key(UNAVAILABLE)).Err(); err != nil {
+		return err
+	}
+
+	// magicnum allocation times
+	if err := x.redis.Del(x.ctx, x.key(ALLOCATED)).Err(); err != nil {
+		return err
+	}
+
+	// magicnums of deleted xrefs
+	if err := x.redis.Del(x.ctx, x.key(RETIRED)).Err(); err != nil {
+		return err
+	}
+
+	// xref events
+	if err := x.redis.Del(x.ctx, x.key(EVENTS)).Err(); err != nil {
+		return err
+	}
+
+	// allocation counters
+	if err := x.resetAllocations(); err != nil {
 		return err
 	}
 
 	count := 0
 	r := bufio.NewScanner(f)
 	for r.Scan() {
-		x.redis.RPush(x.ctx, AVAILABLE, r.Text())
+		x.redis.RPush(x.ctx, x.key(AVAILABLE), r.Text())
 		count++
 	}
 	log.Printf("Successfully added %d records", count)
+	atomic.StoreInt32(&x.initialized, 1)
+	return nil
+}
+
+// InitStore prepares the store by mode. reset replaces the store with the
+// data file, if-empty loads the data file only into an empty store and skip
+// uses the store as is.
+func (x *xrefServer) InitStore(mode, path string) error {
+
+	switch mode {
+	case INIT_RESET:
+		return x.InitData(path)
+	case INIT_IF_EMPTY:
+		n, err := x.redis.Exists(x.ctx, x.key(XMAP), x.key(AVAILABLE), x.key(UNAVAILABLE), x.key(RETIRED)).Result()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return x.InitData(path)
+		}
+		log.Printf("store is not empty, skipping init")
+	case INIT_SKIP:
+	default:
+		return fmt.Errorf("unknown init mode %q", mode)
+	}
+	atomic.StoreInt32(&x.initialized, 1)
 	return nil
 }
 
@@ -78,11 +164,136 @@ func (x *xrefServer) GetXref(ctx context.Context, in *XrefRequest) (*XrefRespons
 		Token: &XREF{
 			Value: xrefRes.XREF.Value,
 		},
+		Lastfour: in.GetLastfour(),
+		Created:  xrefRes.Status == constants.NEW,
 	}, nil
 }
 
+// LookupXref returns the xref for a last 4 without allocating one
+func (x *xrefServer) LookupXref(ctx context.Context, in *XrefRequest) (*XrefResponse, error) {
+	val, err := x.redis.HGet(x.ctx, x.key(XMAP), in.GetLastfour()).Result()
+	if err == redis.Nil {
+		return nil, status.Errorf(codes.NotFound, "no xref for %q", in.GetLastfour())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &XrefResponse{Token: &XREF{Value: val}, Lastfour: in.GetLastfour()}, nil
+}
+
+// DeleteXref removes the xref for a last 4. Its magic number is retired
+// rather than made available again, so the xref is never handed out twice.
+func (x *xrefServer) DeleteXref(ctx context.Context, in *XrefRequest) (*XrefResponse, error) {
+	lastFour := in.GetLastfour()
+
+	// the xref map is watched so the xref retired is the one deleted, not
+	// one a concurrent rotation or create replaced it with
+	var val string
+	del := func(tx *redis.Tx) error {
+		var err error
+		val, err = tx.HGet(x.ctx, x.key(XMAP), lastFour).Result()
+		if err == redis.Nil {
+			return status.Errorf(codes.NotFound, "no xref for %q", lastFour)
+		}
+		if err != nil {
+			return err
+		}
+		magicNum := strings.TrimSuffix(val, lastFour)
+		_, err = tx.TxPipelined(x.ctx, func(pipe redis.Pipeliner) error {
+			pipe.HDel(x.ctx, x.key(XMAP), lastFour)
+			pipe.HDel(x.ctx, x.key(UNAVAILABLE), magicNum)
+			pipe.HDel(x.ctx, x.key(ALLOCATED), magicNum)
+			pipe.HSet(x.ctx, x.key(RETIRED), magicNum, val)
+			x.addEvent(x.ctx, pipe, XrefEvent_DELETED, lastFour, val, magicNum, time.Now())
+			return nil
+		})
+		return err
+	}
+	var err error
+	for i := 0; i < watchAttempts; i++ {
+		if err = x.redis.Watch(x.ctx, del, x.key(XMAP)); err != redis.TxFailedErr {
+			break
+		}
+	}
+	if err == redis.TxFailedErr {
+		return nil, status.Error(codes.Aborted, "xrefs changed during delete, retry")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &XrefResponse{Token: &XREF{Value: val}, Lastfour: lastFour}, nil
+}
+
+// watchAttempts bounds the retries of a watched write racing other writes
+const watchAttempts = 3
+
+// RotateXref replaces the xref for a last 4 with one of a new magic number.
+// The old magic number is retired like on DeleteXref.
+func (x *xrefServer) RotateXref(ctx context.Context, in *XrefRequest) (*XrefResponse, error) {
+	lastFour := in.GetLastfour()
+	if len(lastFour) != 4 {
+		return nil, status.Errorf(codes.InvalidArgument, "lastfour %q must be 4 characters", lastFour)
+	}
+	if _, err := x.redis.HGet(x.ctx, x.key(XMAP), lastFour).Result(); err == redis.Nil {
+		return nil, status.Errorf(codes.NotFound, "no xref for %q", lastFour)
+	} else if err != nil {
+		return nil, err
+	}
+
+	magicNum, err := x.redis.RPop(x.ctx, x.key(AVAILABLE)).Result()
+	if err == redis.Nil {
+		return nil, status.Error(codes.ResourceExhausted, "no magic numbers available")
+	}
+	if err != nil {
+		return nil, err
+	}
+	val := magicNum + lastFour
+
+	// the xref map is watched so a concurrent delete or rotation of the same
+	// last 4 isn't overwritten
+	rotate := func(tx *redis.Tx) error {
+		old, err := tx.HGet(x.ctx, x.key(XMAP), lastFour).Result()
+		if err == redis.Nil {
+			return status.Errorf(codes.NotFound, "no xref for %q", lastFour)
+		}
+		if err != nil {
+			return err
+		}
+		oldMagicNum := strings.TrimSuffix(old, lastFour)
+		rotatedAt := time.Now()
+		_, err = tx.TxPipelined(x.ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(x.ctx, x.key(XMAP), lastFour, val)
+			pipe.HDel(x.ctx, x.key(UNAVAILABLE), oldMagicNum)
+			pipe.HDel(x.ctx, x.key(ALLOCATED), oldMagicNum)
+			pipe.HSet(x.ctx, x.key(RETIRED), oldMagicNum, old)
+			pipe.HSet(x.ctx, x.key(UNAVAILABLE), magicNum, val)
+			pipe.HSet(x.ctx, x.key(ALLOCATED), magicNum, rotatedAt.UnixNano())
+			x.countAllocation(x.ctx, pipe, rotatedAt)
+			x.addEvent(x.ctx, pipe, XrefEvent_ROTATED, lastFour, val, magicNum, rotatedAt)
+			return nil
+		})
+		return err
+	}
+	for i := 0; i < watchAttempts; i++ {
+		if err = x.redis.Watch(x.ctx, rotate, x.key(XMAP)); err != redis.TxFailedErr {
+			break
+		}
+	}
+	if err != nil {
+		// the new magic number was never handed out
+		if err := x.redis.RPush(x.ctx, x.key(AVAILABLE), magicNum).Err(); err != nil {
+			log.Printf("failed to return magic number %s: %v", magicNum, err)
+		}
+		if err == redis.TxFailedErr {
+			return nil, status.Error(codes.Aborted, "xrefs changed during rotation, retry")
+		}
+		return nil, err
+	}
+	return &XrefResponse{Token: &XREF{Value: val}, Lastfour: lastFour, Created: true}, nil
+}
+
 // GetMagicNumbers gets all magic numbers by STATUS
-func (x *xrefServer) GetMagicNumberSummary(ctx context.Context, status *Status) (*MagicNumberSummary, error) {
+func (x *xrefServer) GetMagicNumberSummary(ctx context.Context, in *Status) (*MagicNumberSummary, error) {
 
 	var (
 		s     string
@@ -90,16 +301,16 @@ func (x *xrefServer) GetMagicNumberSummary(ctx context.Context, status *Status)
 		total int64
 	)
 
-	statusType := status.Status.String()
+	statusType := in.Status.String()
 	switch statusType {
 	case string(constants.AVAILABLE):
-		s = AVAILABLE
+		s = x.key(AVAILABLE)
 		total, err = x.redis.LLen(x.ctx, s).Result()
 	case string(constants.UNAVAILABLE):
-		s = UNAVAILABLE
+		s = x.key(UNAVAILABLE)
 		total, err = x.redis.HLen(x.ctx, s).Result()
 	default:
-		return nil, errors.New("type not found")
+		return nil, status.Error(codes.InvalidArgument, "type not found")
 	}
 
 	if err != nil {
@@ -109,11 +320,13 @@ func (x *xrefServer) GetMagicNumberSummary(ctx context.Context, status *Status)
 	return &MagicNumberSummary{Total: uint64(total)}, nil
 }
 
-// AddXrefs accepts a stream of requests and returns a summary
+// AddXrefs accepts a stream of requests and returns a summary. Keys which
+// fail are counted in total_failed rather than ending the stream, as the
+// keys before them are already allocated.
 func (x *xrefServer) AddXrefs(stream XrefService_AddXrefsServer) error {
 
 	// counters
-	totalNew, totalUpdated := 0, 0
+	totalNew, totalUpdated, totalFailed := 0, 0, 0
 	startTime := time.Now()
 
 	// loop stream and build summary
@@ -124,7 +337,8 @@ func (x *xrefServer) AddXrefs(stream XrefService_AddXrefsServer) error {
 			return stream.SendAndClose(&XrefSummary{
 				TotalNew:     uint32(totalNew),
 				TotalUpdated: uint32(totalUpdated),
-				ElapsedTime:  uint32(endTime.Sub(startTime)),
+				TotalFailed:  uint32(totalFailed),
+				ElapsedTime:  uint32(endTime.Sub(startTime).Milliseconds()),
 			})
 		}
 		if err != nil {
@@ -133,7 +347,8 @@ func (x *xrefServer) AddXrefs(stream XrefService_AddXrefsServer) error {
 
 		xrefRes, err := x.getXref(&models.XrefRequest{LastFour: xrefReq.GetLastfour()})
 		if err != nil {
-			return err
+			totalFailed++
+			continue
 		}
 
 		// build counts
@@ -146,38 +361,147 @@ func (x *xrefServer) AddXrefs(stream XrefService_AddXrefsServer) error {
 	}
 }
 
-// GetMagicNumbers gets all magic numbers by STATUS
-func (x *xrefServer) GetMagicNumbers(status *Status, stream XrefService_GetMagicNumbersServer) error {
+// GetMagicNumbers streams magic numbers by STATUS, a page at a time when
+// page_size is set
+func (x *xrefServer) GetMagicNumbers(in *Status, stream XrefService_GetMagicNumbersServer) error {
 
-	var (
-		s   string
-		err error
-		res []string
-	)
+	token, err := parsePageToken(in.GetPageToken())
+	if err != nil {
+		return err
+	}
+	page := &magicNumberPage{stream: stream, size: int(in.GetPageSize())}
 
-	statusType := status.Status.String()
+	statusType := in.Status.String()
 	switch statusType {
 	case string(constants.AVAILABLE):
-		s = AVAILABLE
-		res, err = x.redis.LRange(x.ctx, s, 0, -1).Result()
+		return x.streamAvailable(in, token, page)
 	case string(constants.UNAVAILABLE):
-		s = UNAVAILABLE
-		res, err = x.redis.HKeys(x.ctx, s).Result()
+		return x.streamUnavailable(in, token, page)
 	default:
-		return errors.New("type not found")
+		return status.Error(codes.InvalidArgument, "type not found")
 	}
+}
 
-	if err != nil {
-		return err
+// streamAvailable walks the available list in LRANGE windows
+func (x *xrefServer) streamAvailable(in *Status, token pageToken, page *magicNumberPage) error {
+
+	prefix := in.GetPrefix()
+	offset := int64(token.cursor)
+	for {
+		res, err := x.redis.LRange(x.ctx, x.key(AVAILABLE), offset, offset+scanBatch-1).Result()
+		if err != nil {
+			return err
+		}
+
+		for i, magicNum := range res {
+			if !strings.HasPrefix(magicNum, prefix) {
+				continue
+			}
+			if page.full() {
+				return page.close(true)
+			}
+			next := pageToken{cursor: uint64(offset) + uint64(i) + 1}
+			mn := &MagicNumber{Value: magicNum, PageToken: next.String()}
+			if in.GetIncludeDetails() {
+				mn.Detail = &MagicNumberDetail{Status: Status_AVAILABLE}
+			}
+			if err := page.add(mn); err != nil {
+				return err
+			}
+		}
+
+		if len(res) < scanBatch {
+			return page.close(false)
+		}
+		offset += scanBatch
 	}
+}
 
-	// stream all magic numbers to client
-	for _, magicNum := range res {
-		if err := stream.Send(&MagicNumber{Value: magicNum}); err != nil {
+// streamUnavailable walks the unavailable hash with HSCAN
+func (x *xrefServer) streamUnavailable(in *Status, token pageToken, page *magicNumberPage) error {
+
+	cursor, after := token.cursor, token.after
+	for {
+		// res holds field, value pairs
+		res, next, err := x.redis.HScan(x.ctx, x.key(UNAVAILABLE), cursor, matchPrefix(in.GetPrefix()), scanBatch).Result()
+		if err != nil {
 			return err
 		}
+
+		// resume after the last field sent, a batch it is no longer in is
+		// sent again whole as HSCAN may return entries more than once anyway
+		n := len(res) / 2
+		start := 0
+		if after != "" {
+			for i := 0; i < n; i++ {
+				if res[i*2] == after {
+					start = i + 1
+					break
+				}
+			}
+		}
+
+		var details []*MagicNumberDetail
+		if in.GetIncludeDetails() && start < n {
+			if details, err = x.unavailableDetails(res); err != nil {
+				return err
+			}
+		}
+
+		for i := start; i < n; i++ {
+			if page.full() {
+				return page.close(true)
+			}
+			t := pageToken{cursor: cursor, after: res[i*2]}
+			if i == n-1 {
+				t = pageToken{cursor: next}
+			}
+			mn := &MagicNumber{Value: res[i*2], PageToken: t.String()}
+			if details != nil {
+				mn.Detail = details[i]
+			}
+			if err := page.add(mn); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return page.close(false)
+		}
+		cursor, after = next, ""
 	}
-	return nil
+}
+
+// unavailableDetails builds the mapping details of a batch of magic number,
+// xref pairs read from the unavailable hash
+func (x *xrefServer) unavailableDetails(pairs []string) ([]*MagicNumberDetail, error) {
+
+	magicNums := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		magicNums = append(magicNums, pairs[i])
+	}
+	allocated, err := x.redis.HMGet(x.ctx, x.key(ALLOCATED), magicNums...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]*MagicNumberDetail, len(magicNums))
+	for i, magicNum := range magicNums {
+		val := pairs[i*2+1]
+		details[i] = &MagicNumberDetail{
+			Lastfour: strings.TrimPrefix(val, magicNum),
+			Token:    &XREF{Value: val},
+			Status:   Status_UNAVAILABLE,
+		}
+
+		// magic numbers allocated before times were recorded have none
+		if ts, ok := allocated[i].(string); ok {
+			if nanos, err := strconv.ParseInt(ts, 10, 64); err == nil {
+				details[i].AllocatedAt = timestamppb.New(time.Unix(0, nanos))
+			}
+		}
+	}
+	return details, nil
 }
 
 // GetXrefs is a bidirectional stream for getting and sending xrefs
@@ -190,11 +514,19 @@ func (x *xrefServer) GetXrefs(stream XrefService_GetXrefsServer) error {
 			return nil
 		}
 		if err != nil {
-			return nil
+			return err
 		}
 
+		// failures are answered in band so one bad key doesn't end the stream
+		res := &XrefResponse{Lastfour: xrefReq.GetLastfour()}
 		xrefRes, err := x.getXref(&models.XrefRequest{LastFour: xrefReq.GetLastfour()})
-		if err := stream.Send(&XrefResponse{Token: &XREF{Value: xrefRes.XREF.Value}}); err != nil {
+		if err != nil {
+			res.Error = status.Convert(err).Proto()
+		} else {
+			res.Token = &XREF{Value: xrefRes.XREF.Value}
+			res.Created = xrefRes.Status == constants.NEW
+		}
+		if err := stream.Send(res); err != nil {
 			return err
 		}
 	}
@@ -205,29 +537,47 @@ func (x *xrefServer) getXref(xrefReq *models.XrefRequest) (*models.XrefResponse,
 
 	// has to be len 4
 	if len(xrefReq.LastFour) != 4 {
-		return nil, errors.New("bad request")
+		return nil, status.Errorf(codes.InvalidArgument, "lastfour %q must be 4 characters", xrefReq.LastFour)
 	}
 
 	// magic num status
-	status := constants.EXISTING
+	state := constants.EXISTING
 
-	val, err := x.redis.HGet(x.ctx, XMAP, xrefReq.LastFour).Result()
+	val, err := x.redis.HGet(x.ctx, x.key(XMAP), xrefReq.LastFour).Result()
 	if err != nil {
-		magicNum, err := x.redis.RPop(x.ctx, AVAILABLE).Result()
+		magicNum, err := x.redis.RPop(x.ctx, x.key(AVAILABLE)).Result()
+		if err == redis.Nil {
+			return nil, status.Error(codes.ResourceExhausted, "no magic numbers available")
+		}
 		if err != nil {
 			return nil, err
 		}
 
-		status = constants.NEW
+		state = constants.NEW
 		val = magicNum + xrefReq.LastFour
-		x.redis.HSet(x.ctx, XMAP, xrefReq.LastFour, val)
 
-		// write magic num to UNAVAILABLE map
-		go x.redis.HSet(x.ctx, UNAVAILABLE, magicNum, val)
+		// the xref, its magic number's allocation and its event are written
+		// together, so the event feed is in the order of the store
+		allocatedAt := time.Now()
+		_, err = x.redis.TxPipelined(x.ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(x.ctx, x.key(XMAP), xrefReq.LastFour, val)
+			pipe.HSet(x.ctx, x.key(UNAVAILABLE), magicNum, val)
+			pipe.HSet(x.ctx, x.key(ALLOCATED), magicNum, allocatedAt.UnixNano())
+			x.countAllocation(x.ctx, pipe, allocatedAt)
+			x.addEvent(x.ctx, pipe, XrefEvent_CREATED, xrefReq.LastFour, val, magicNum, allocatedAt)
+			return nil
+		})
+		if err != nil {
+			// the magic number was never handed out
+			if err := x.redis.RPush(x.ctx, x.key(AVAILABLE), magicNum).Err(); err != nil {
+				log.Printf("failed to return magic number %s: %v", magicNum, err)
+			}
+			return nil, err
+		}
 	}
 
 	return &models.XrefResponse{
 		XREF:   models.Xref{Value: val},
-		Status: status,
+		Status: state,
 	}, nil
 }